@@ -0,0 +1,84 @@
+// Command secrets creates and inspects the encrypted secrets file read by
+// the server through SECRETS_FILE and SECRETS_KEY.
+//
+//	secrets keygen                     print a new base64 key
+//	secrets encrypt < plain.yaml       encrypt YAML from stdin
+//	secrets decrypt < secrets.enc      decrypt a secrets file to stdout
+//
+// encrypt and decrypt read the key from SECRETS_KEY or SECRETS_KEY_FILE.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gin-template/pkg/config"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: secrets keygen|encrypt|decrypt")
+		os.Exit(2)
+	}
+
+	if err := run(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, "secrets:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string) error {
+	if command == "keygen" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+		return nil
+	}
+
+	key, err := readKey()
+	if err != nil {
+		return err
+	}
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	var output []byte
+	switch command {
+	case "encrypt":
+		output, err = config.EncryptSecrets(key, input)
+	case "decrypt":
+		output, err = config.DecryptSecrets(key, input)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(output)
+	return err
+}
+
+func readKey() ([]byte, error) {
+	encoded := os.Getenv("SECRETS_KEY")
+	if file := os.Getenv("SECRETS_KEY_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		encoded = strings.TrimSpace(string(data))
+	}
+	if encoded == "" {
+		return nil, errors.New("SECRETS_KEY or SECRETS_KEY_FILE must be set")
+	}
+	return config.ParseSecretsKey(encoded)
+}
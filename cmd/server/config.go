@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"gin-template/pkg/config"
+)
+
+const configUsage = `usage: server [-config file] config <command> [flags]
+
+commands:
+  show      print the effective configuration and where each value came from
+  validate  check the configuration and report every invalid field`
+
+// runConfig implements the `config` subcommand and returns the exit code.
+func runConfig(defaultConfigFile string, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, configUsage)
+		return exitUsage
+	}
+
+	command := args[0]
+	fs := flag.NewFlagSet("config "+command, flag.ContinueOnError)
+	configFile := fs.String("config", defaultConfigFile, "path to a YAML or TOML config file")
+	asJSON := fs.Bool("json", false, "print as JSON (show only)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	cfg, sources, err := config.Resolve(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	switch command {
+	case "show":
+		entries := config.Describe(cfg, sources)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(entries); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitError
+			}
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", e.Key, e.Value, e.Source)
+			}
+			w.Flush()
+		}
+	case "validate":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown config command %q\n\n%s\n", command, configUsage)
+		return exitUsage
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if command == "validate" {
+		fmt.Println("configuration is valid")
+	}
+	return exitOK
+}
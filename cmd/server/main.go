@@ -18,41 +18,114 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
 	"gin-template/pkg/config"
 	"gin-template/pkg/database"
-	"gin-template/pkg/router"
+	"gin-template/pkg/models"
+	"gin-template/pkg/version"
 
-	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
 )
 
+// command is a subcommand of the server binary.
+type command struct {
+	summary string
+	run     func(app *app, args []string) int
+}
+
+// commands maps subcommand names to their implementations. `config` is
+// handled separately because it must work on an invalid configuration.
+var commands = map[string]command{
+	"serve":   {"run the API server (default)", runServe},
+	"migrate": {"apply, roll back or inspect the database schema", runMigrate},
+	"seed":    {"insert sample data", runSeed},
+	"routes":  {"list registered routes and their bound request types", runRoutes},
+	"openapi": {"print the OpenAPI (Swagger) specification", runOpenAPI},
+	"user":    {"list, show, create, update and delete users", runUser},
+}
+
+// app carries what every command shares.
+type app struct {
+	cfg        *config.Config
+	configFile string
+}
+
+// openDB connects to the configured database, retrying while it is not
+// reachable, without migrating it.
+func (a *app) openDB() (*gorm.DB, error) {
+	return database.Connect(context.Background(), a.cfg.Database)
+}
+
 func main() {
-	// Initialize configuration
-	cfg := config.New()
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	showVersion := flag.Bool("version", false, "print build information and exit")
+	flag.Usage = usage
+	flag.Parse()
 
-	// Setup logger
-	config.SetupLogger(cfg.Log)
-	logger := log.With().Str("component", "main").Logger()
+	if *showVersion {
+		fmt.Println("gin-template " + version.Get().String())
+		os.Exit(exitOK)
+	}
 
-	logger.Info().Msg("Starting Gin Template API Server")
+	name, args := "serve", flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "config" {
+		os.Exit(runConfig(*configFile, args))
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(exitUsage)
+	}
 
-	// Initialize database connection
-	db, err := database.New(cfg.Database)
+	// Initialize configuration
+	cfg, err := config.Load(*configFile)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed to connect to database")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
 	}
-	logger.Info().Str("driver", cfg.Database.Driver).Msg("Database connected successfully")
 
-	// Initialize router with new architecture
-	r := router.New(db)
+	// Setup logger; commands other than serve keep stdout for their output
+	if name == "serve" {
+		config.SetupLogger(cfg.Log)
+	} else {
+		config.SetupLoggerOutput(cfg.Log, os.Stderr)
+	}
+	// Mask model fields tagged `log:"sensitive"`
+	config.RegisterSensitive(models.Sensitive()...)
 
-	// Start server
-	logger.Info().
-		Str("port", cfg.Server.Port).
-		Str("log_level", cfg.Log.Level).
-		Str("log_format", cfg.Log.Format).
-		Msg("Server starting with new architecture")
+	os.Exit(cmd.run(&app{cfg: cfg, configFile: *configFile}, args))
+}
+
+func usage() {
+	names := make([]string, 0, len(commands)+1)
+	for name := range commands {
+		names = append(names, name)
+	}
+	names = append(names, "config")
+	sort.Strings(names)
 
-	if err := r.Run(":" + cfg.Server.Port); err != nil {
-		logger.Fatal().Err(err).Msg("Failed to start server")
+	var b strings.Builder
+	b.WriteString("usage: server [-config file] <command> [args]\n\ncommands:\n")
+	for _, name := range names {
+		summary := "show or validate the effective configuration"
+		if cmd, ok := commands[name]; ok {
+			summary = cmd.summary
+		}
+		fmt.Fprintf(&b, "  %-8s  %s\n", name, summary)
 	}
+	b.WriteString("\nflags:\n")
+	fmt.Fprint(os.Stderr, b.String())
+	flag.PrintDefaults()
 }
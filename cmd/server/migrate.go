@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"gin-template/pkg/database"
+	"gin-template/pkg/migrate"
+)
+
+const migrateUsage = `usage: server migrate <up|down|status|unlock> [flags]
+
+  up      apply pending migrations (-to version, -dry-run)
+  down    roll back the last migration (-steps n, -dry-run; requires -force)
+  status  list applied, pending and unknown migrations; exits 1 if any is pending
+  unlock  release the migration lock left by an instance that died migrating`
+
+// runMigrate implements `migrate up|down|status|unlock`.
+func runMigrate(app *app, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	to := fs.Int64("to", 0, "apply migrations up to this version (up only; default latest)")
+	steps := fs.Int("steps", 1, "number of migrations to roll back (down only)")
+	dryRun := fs.Bool("dry-run", false, "print the migrations that would run without running them")
+	force := fs.Bool("force", false, "confirm rolling back (down only)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	db, err := app.openDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "connect to database:", err)
+		return exitError
+	}
+	migrator, err := database.NewMigrator(db, app.cfg.Database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return exitError
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		if *dryRun {
+			pending, err := migrator.Pending(ctx, *to)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "migrate:", err)
+				return exitError
+			}
+			printPlan("apply", pending, true)
+			return exitOK
+		}
+		applied, err := migrator.Up(ctx, *to)
+		for _, m := range applied {
+			fmt.Println("applied", m)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "migrate:", err)
+			return exitError
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		if *steps < 1 {
+			fmt.Fprintln(os.Stderr, "-steps must be at least 1")
+			return exitUsage
+		}
+		if *dryRun {
+			rollbacks, err := migrator.Rollbacks(ctx, *steps)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "migrate:", err)
+				return exitError
+			}
+			printPlan("roll back", rollbacks, false)
+			return exitOK
+		}
+		if !*force {
+			fmt.Fprintln(os.Stderr, "migrate down may drop tables and their data; rerun with -force, or -dry-run to preview")
+			return exitUsage
+		}
+		reverted, err := migrator.Down(ctx, *steps)
+		for _, m := range reverted {
+			fmt.Println("rolled back", m)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "migrate:", err)
+			return exitError
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no migrations to roll back")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "status:", err)
+			return exitError
+		}
+		pending := false
+		for _, s := range statuses {
+			switch {
+			case s.Unknown:
+				fmt.Printf("%-40s unknown, applied %s\n", s.Migration, s.AppliedAt.Format(time.RFC3339))
+			case s.Applied:
+				fmt.Printf("%-40s applied %s\n", s.Migration, s.AppliedAt.Format(time.RFC3339))
+			default:
+				pending = true
+				fmt.Printf("%-40s pending\n", s.Migration)
+			}
+		}
+		if pending {
+			return exitError
+		}
+	case "unlock":
+		released, err := migrator.Unlock(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "unlock:", err)
+			return exitError
+		}
+		if released {
+			fmt.Println("migration lock released")
+		} else {
+			fmt.Println("migration lock is not held")
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n\n%s\n", args[0], migrateUsage)
+		return exitUsage
+	}
+	return exitOK
+}
+
+// printPlan prints the migrations a dry run would run, with their SQL.
+func printPlan(action string, migrations []migrate.Migration, up bool) {
+	if len(migrations) == 0 {
+		fmt.Println("nothing to " + action)
+		return
+	}
+	for _, m := range migrations {
+		step := m.Down
+		if up {
+			step = m.Up
+		}
+		fmt.Printf("-- %s %s\n", action, m)
+		switch {
+		case step.SQL != "":
+			fmt.Println(step.SQL)
+		case step.Func != nil:
+			fmt.Println("-- (Go function)")
+			fmt.Println()
+		default:
+			fmt.Println("-- (no down step: cannot be rolled back)")
+			fmt.Println()
+		}
+	}
+}
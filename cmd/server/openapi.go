@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gin-template/docs"
+	"gin-template/pkg/version"
+)
+
+// runOpenAPI prints the generated Swagger specification.
+func runOpenAPI(app *app, args []string) int {
+	fs := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	output := fs.String("o", "", "write the spec to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	docs.SwaggerInfo.Version = version.Get().Version
+	spec := docs.SwaggerInfo.ReadDoc()
+
+	if *output == "" {
+		fmt.Println(spec)
+		return exitOK
+	}
+	if err := os.WriteFile(*output, []byte(spec+"\n"), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return exitOK
+}
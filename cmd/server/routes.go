@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/router"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runRoutes prints the routes registered by router.New.
+func runRoutes(app *app, args []string) int {
+	fs := flag.NewFlagSet("routes", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print as JSON")
+	admin := fs.Bool("admin", false, "list the admin listener's routes instead")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	// Routes are only inspected, never served, so no database is needed.
+	gin.SetMode(gin.ReleaseMode)
+	provider := config.NewProvider(app.cfg)
+	engine := router.New(nil, provider)
+	if *admin {
+		engine = router.NewAdmin(provider)
+	}
+	routes := router.Routes(engine)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(routes); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tHANDLER\tBINDS")
+	for _, r := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Method, r.Path, r.Handler, strings.Join(r.Binds, ", "))
+	}
+	w.Flush()
+	return exitOK
+}
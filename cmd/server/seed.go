@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"gin-template/pkg/database"
+	"gin-template/pkg/models"
+	"gin-template/pkg/service"
+)
+
+// seedUsers is the sample data inserted by `seed`.
+var seedUsers = []models.CreateUserRequest{
+	{Name: "John Doe", Email: "john.doe@example.com", Age: 30, Phone: "1234567890"},
+	{Name: "Jane Smith", Email: "jane.smith@example.com", Age: 28, Phone: "0987654321"},
+	{Name: "Bob Johnson", Email: "bob.johnson@example.com", Age: 35},
+}
+
+// runSeed inserts sample users, skipping those that already exist.
+func runSeed(app *app, args []string) int {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	db, err := app.openDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "connect to database:", err)
+		return exitError
+	}
+	if err := database.Migrate(context.Background(), db, app.cfg.Database); err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return exitError
+	}
+
+	userService := service.NewUserService(database.NewCluster(db))
+	created := 0
+	for _, req := range seedUsers {
+		var existing []models.User
+		if err := db.Where("email = ?", req.Email).Limit(1).Find(&existing).Error; err != nil {
+			fmt.Fprintln(os.Stderr, "seed:", err)
+			return exitError
+		}
+		if len(existing) > 0 {
+			continue
+		}
+
+		if _, err := userService.CreateUser(context.Background(), &req); err != nil {
+			fmt.Fprintln(os.Stderr, "seed:", err)
+			return exitError
+		}
+		created++
+	}
+
+	fmt.Printf("seeded %d user(s), %d already present\n", created, len(seedUsers)-created)
+	return exitOK
+}
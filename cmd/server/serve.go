@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"maps"
+	"os/signal"
+	"syscall"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/database"
+	"gin-template/pkg/health"
+	"gin-template/pkg/lifecycle"
+	"gin-template/pkg/router"
+	"gin-template/pkg/server"
+	"gin-template/pkg/tracing"
+	"gin-template/pkg/version"
+)
+
+// Exit codes reported by the server.
+const (
+	exitOK = 0
+	// exitError means the command or server failed, or the server stopped
+	// unexpectedly.
+	exitError = 1
+	// exitUsage means the command line was invalid.
+	exitUsage = 2
+	// exitDrainTimeout means in-flight requests were cut off at shutdown.
+	exitDrainTimeout = 3
+	// exitHookFailed means a shutdown hook (e.g. closing the database) failed.
+	exitHookFailed = 4
+)
+
+// runServe runs the API server until SIGINT or SIGTERM.
+func runServe(app *app, args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	cfg := app.cfg
+	logger := config.GetLogger("main")
+
+	build := version.Get()
+	logger.Info().
+		Str("version", build.Version).
+		Str("commit", build.Commit).
+		Str("build_time", build.BuildTime).
+		Str("go_version", build.GoVersion).
+		Str("env", cfg.Env).
+		Str("config_file", app.configFile).
+		Msg("Starting Gin Template API Server")
+
+	// The first SIGINT/SIGTERM starts a graceful shutdown; a second one
+	// kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// Closed once the outcome below is logged; later events skip the file
+	defer func() {
+		config.FlushLogSampling()
+		_ = config.CloseLogFile()
+	}()
+
+	lc := lifecycle.New()
+
+	// Flushes pending spans once requests and the database are done
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to set up tracing")
+		return exitError
+	}
+	lc.OnShutdown("tracing", shutdownTracing)
+
+	// Re-apply reloadable settings when the config file changes or on SIGHUP
+	provider := config.NewProvider(cfg)
+	provider.Subscribe(func(old, new *config.Config) {
+		if old.Log.Level != new.Log.Level || old.Log.Format != new.Log.Format ||
+			!maps.Equal(old.Log.Levels, new.Log.Levels) || old.Log.Sampling != new.Log.Sampling {
+			config.SetupLogger(new.Log)
+		}
+	})
+	// Health checks behind /livez and /readyz
+	checks := health.NewRegistry(cfg.Health.Timeout, cfg.Health.CacheTTL)
+	// A watcher that failed to start stays broken after a restart, so it
+	// only affects readiness
+	watcher := health.NewWorker(0)
+	checks.Register("config watcher", watcher)
+
+	go func() {
+		err := config.Watch(ctx, app.configFile, provider)
+		if err != nil {
+			logger.Error().Err(err).Msg("Config watcher stopped")
+		}
+		watcher.Stop(err)
+	}()
+
+	// Initialize database connection
+	db, err := database.New(ctx, cfg.Database)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to connect to database")
+		return exitError
+	}
+	logger.Info().
+		Str("driver", cfg.Database.Driver).
+		Int("max_open_conns", cfg.Database.Pool.MaxOpenConns).
+		Msg("Database connected successfully")
+
+	if err := database.RegisterHealthChecks(checks, db, cfg.Database, uint64(cfg.Health.MinFreeDiskMB)<<20); err != nil {
+		logger.Error().Err(err).Msg("Failed to register database health checks")
+		return exitError
+	}
+
+	// Reads go to the replicas, if any; unreachable ones are retried by the
+	// monitor
+	cluster := database.OpenCluster(ctx, db, cfg.Database)
+	if n := len(cfg.Database.Replicas.DSNs); n > 0 {
+		logger.Info().Int("replicas", n).Str("policy", cfg.Database.Replicas.Policy).Msg("Routing reads to replicas")
+	}
+	go cluster.Monitor(ctx)
+
+	lc.OnShutdown("database", func(context.Context) error {
+		return cluster.Close()
+	})
+
+	// Initialize router with new architecture
+	r := router.New(db, provider, router.WithReadiness(lc.Ready), router.WithHealth(checks), router.WithCluster(cluster))
+	admin := router.NewAdmin(provider, router.WithReadiness(lc.Ready), router.WithHealth(checks), router.WithCluster(cluster))
+
+	// Start server
+	srv, err := server.New(cfg.Server, r, server.WithAdmin(admin))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to configure server")
+		return exitError
+	}
+
+	logger.Info().
+		Str("port", cfg.Server.Port).
+		Bool("tls", cfg.Server.TLS.Enabled).
+		Bool("admin", cfg.Server.Admin.Enabled).
+		Bool("tracing", cfg.Tracing.Enabled).
+		Str("log_level", cfg.Log.Level).
+		Str("log_format", cfg.Log.Format).
+		Msg("Server starting with new architecture")
+
+	err = srv.Run(ctx, lc)
+
+	var hookErr *lifecycle.HookError
+	switch {
+	case err == nil:
+		logger.Info().Msg("Server stopped")
+		return exitOK
+	case errors.Is(err, server.ErrServe):
+		logger.Error().Err(err).Msg("Server failed")
+		return exitError
+	case errors.Is(err, server.ErrDrainTimeout):
+		logger.Error().Err(err).Msg("Server stopped without draining all requests")
+		return exitDrainTimeout
+	case errors.As(err, &hookErr):
+		logger.Error().Err(err).Msg("Server stopped, cleanup incomplete")
+		return exitHookFailed
+	default:
+		logger.Error().Err(err).Msg("Server stopped with an error")
+		return exitError
+	}
+}
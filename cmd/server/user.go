@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"gin-template/pkg/database"
+	"gin-template/pkg/models"
+	"gin-template/pkg/service"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+const userUsage = `usage: server user <command> [flags]
+
+commands:
+  list    [-page n] [-page-size n] [-name s] [-email s]
+  get     <id>
+  create  -name s -email s [-age n] [-phone s]
+  update  <id> [-name s] [-email s] [-age n] [-phone s]
+  delete  <id>`
+
+// runUser implements user administration on top of service.UserService,
+// applying the same validation rules as the API.
+func runUser(app *app, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		return exitUsage
+	}
+	command, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("user "+command, flag.ContinueOnError)
+	name := fs.String("name", "", "user name")
+	email := fs.String("email", "", "email address")
+	age := fs.Int("age", 0, "age")
+	phone := fs.String("phone", "", "phone number")
+	page := fs.Int("page", 1, "page number (list)")
+	pageSize := fs.Int("page-size", 10, "page size (list)")
+
+	// Commands taking an ID accept it before the flags.
+	var id uint64
+	if command == "get" || command == "update" || command == "delete" {
+		if len(args) == 0 {
+			fmt.Fprintf(os.Stderr, "user %s: missing user ID\n", command)
+			return exitUsage
+		}
+		var err error
+		if id, err = strconv.ParseUint(args[0], 10, 32); err != nil {
+			fmt.Fprintf(os.Stderr, "user %s: invalid user ID %q\n", command, args[0])
+			return exitUsage
+		}
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	db, err := app.openDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "connect to database:", err)
+		return exitError
+	}
+	if err := database.Migrate(context.Background(), db, app.cfg.Database); err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return exitError
+	}
+	userService := service.NewUserService(database.NewCluster(db))
+	ctx := context.Background()
+
+	var result any
+	switch command {
+	case "list":
+		query := models.GetUsersQuery{Page: *page, PageSize: *pageSize, Name: *name, Email: *email}
+		if err := binding.Validator.ValidateStruct(&query); err != nil {
+			fmt.Fprintln(os.Stderr, "user list:", err)
+			return exitUsage
+		}
+		users, total, err := userService.GetUsers(ctx, &query)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "user list:", err)
+			return exitError
+		}
+		result = map[string]any{"users": users, "total": total, "page": query.Page, "page_size": query.PageSize}
+	case "get":
+		result, err = userService.GetUser(ctx, uint(id))
+	case "create":
+		req := models.CreateUserRequest{Name: *name, Email: *email, Age: *age, Phone: *phone}
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			fmt.Fprintln(os.Stderr, "user create:", err)
+			return exitUsage
+		}
+		result, err = userService.CreateUser(ctx, &req)
+	case "update":
+		// Only flags given on the command line are updated.
+		var req models.UpdateUserRequest
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "name":
+				req.Name = name
+			case "email":
+				req.Email = email
+			case "age":
+				req.Age = age
+			case "phone":
+				req.Phone = phone
+			}
+		})
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			fmt.Fprintln(os.Stderr, "user update:", err)
+			return exitUsage
+		}
+		result, err = userService.UpdateUser(ctx, uint(id), &req)
+	case "delete":
+		err = userService.DeleteUser(ctx, uint(id))
+		result = map[string]any{"deleted": id}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown user command %q\n\n%s\n", command, userUsage)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "user %s: %v\n", command, err)
+		return exitError
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return exitOK
+}
@@ -2,42 +2,432 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
+// Config is the root application configuration.
+//
+// Every field carries a `config` tag naming its key in configuration files
+// (YAML or TOML) and, optionally, an `env` tag naming the environment
+// variable that overrides it. Validation rules live in `validate` tags.
+// Fields tagged `reload:"true"` (and everything below them) are re-applied
+// on a live reload; all other changes need a restart.
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Log      LogConfig
+	Env      string         `config:"env" env:"APP_ENV" validate:"oneof=dev staging prod"`
+	Server   ServerConfig   `config:"server"`
+	Database DatabaseConfig `config:"database"`
+	Log      LogConfig      `config:"log"`
+	Tracing  TracingConfig  `config:"tracing"`
+	Health   HealthConfig   `config:"health"`
 }
 
 type ServerConfig struct {
-	Port string
+	Port string `config:"port" env:"PORT" validate:"required,port"`
+
+	// Listen lists the addresses the API is served on; see
+	// ParseListenAddress for the format. When empty the server listens on
+	// TCP port Port.
+	Listen     []string         `config:"listen" env:"SERVER_LISTEN" validate:"dive,listen"`
+	UnixSocket UnixSocketConfig `config:"unix_socket"`
+
+	CORS      CORSConfig      `config:"cors" reload:"true"`
+	RateLimit RateLimitConfig `config:"rate_limit" reload:"true"`
+
+	// Timeouts and limits applied to the underlying http.Server.
+	ReadTimeout       time.Duration `config:"read_timeout" env:"SERVER_READ_TIMEOUT" validate:"gte=0"`
+	ReadHeaderTimeout time.Duration `config:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" validate:"gte=0"`
+	WriteTimeout      time.Duration `config:"write_timeout" env:"SERVER_WRITE_TIMEOUT" validate:"gte=0"`
+	IdleTimeout       time.Duration `config:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" validate:"gte=0"`
+	MaxHeaderBytes    int           `config:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" validate:"gte=0"`
+
+	// ShutdownDelay keeps serving after readiness starts failing so load
+	// balancers can stop routing traffic; ShutdownTimeout bounds how long
+	// in-flight requests, and then shutdown hooks, may take.
+	ShutdownDelay   time.Duration `config:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY" validate:"gte=0"`
+	ShutdownTimeout time.Duration `config:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" validate:"gt=0"`
+
+	TLS TLSConfig `config:"tls"`
+
+	Admin   AdminConfig   `config:"admin"`
+	Metrics MetricsConfig `config:"metrics"`
+	Debug   DebugConfig   `config:"debug" reload:"true"`
+}
+
+// DebugConfig exposes profiling and runtime diagnostics under /debug,
+// authenticated with the admin token, on the admin listener when it is
+// enabled and on the public one otherwise. It can be switched on with a
+// live reload while investigating a slow instance.
+type DebugConfig struct {
+	Enabled bool `config:"enabled" env:"DEBUG_ENABLED"`
+	// MaxProfileDuration bounds CPU profiles and execution traces.
+	MaxProfileDuration time.Duration `config:"max_profile_duration" env:"DEBUG_MAX_PROFILE_DURATION" validate:"gt=0"`
+}
+
+// MetricsConfig exposes Prometheus metrics at /metrics, on the admin
+// listener when it is enabled and on the public one otherwise.
+type MetricsConfig struct {
+	Enabled bool `config:"enabled" env:"METRICS_ENABLED"`
+}
+
+// AdminConfig enables a second, plain HTTP listener for operational
+// endpoints (health, readiness, build info, diagnostics, log level control).
+// While it is enabled the public listener no longer serves them.
+type AdminConfig struct {
+	Enabled bool   `config:"enabled" env:"ADMIN_ENABLED"`
+	Host    string `config:"host" env:"ADMIN_HOST"`
+	Port    string `config:"port" env:"ADMIN_PORT" validate:"required_if=Enabled true,omitempty,port"`
+
+	// Token authenticates requests to endpoints that change the running
+	// server, such as log level control, as "Authorization: Bearer
+	// <token>". Those endpoints are refused while it is empty.
+	Token Secret `config:"token" env:"ADMIN_TOKEN"`
+}
+
+// TLSConfig enables HTTPS and, optionally, client certificate
+// verification (mTLS). Certificate, key and CA files are watched and
+// reloaded when they change.
+type TLSConfig struct {
+	Enabled  bool   `config:"enabled" env:"TLS_ENABLED"`
+	CertFile string `config:"cert_file" env:"TLS_CERT_FILE" validate:"required_if=Enabled true"`
+	KeyFile  string `config:"key_file" env:"TLS_KEY_FILE" validate:"required_if=Enabled true"`
+
+	// ClientAuth is "none", "optional" (verify a certificate if one is
+	// sent) or "require" (reject clients without a valid certificate).
+	ClientAuth   string `config:"client_auth" env:"TLS_CLIENT_AUTH" validate:"oneof=none optional require"`
+	ClientCAFile string `config:"client_ca_file" env:"TLS_CLIENT_CA_FILE" validate:"required_unless=ClientAuth none"`
+
+	MinVersion string `config:"min_version" env:"TLS_MIN_VERSION" validate:"oneof=1.2 1.3"`
+}
+
+// UnixSocketConfig sets the permissions of Unix domain sockets created for
+// "unix://" listen addresses.
+type UnixSocketConfig struct {
+	// Mode is an octal file mode such as "0660".
+	Mode  string `config:"mode" env:"UNIX_SOCKET_MODE" validate:"omitempty,filemode"`
+	Owner string `config:"owner" env:"UNIX_SOCKET_OWNER"`
+	Group string `config:"group" env:"UNIX_SOCKET_GROUP"`
+}
+
+// CORSConfig controls which origins may call the API from a browser.
+type CORSConfig struct {
+	AllowedOrigins []string `config:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" validate:"required,dive,required"`
+}
+
+// RateLimitConfig configures the per-client token bucket applied to the API.
+type RateLimitConfig struct {
+	Enabled           bool    `config:"enabled" env:"RATE_LIMIT_ENABLED"`
+	RequestsPerSecond float64 `config:"requests_per_second" env:"RATE_LIMIT_RPS" validate:"required_if=Enabled true,gte=0"`
+	Burst             int     `config:"burst" env:"RATE_LIMIT_BURST" validate:"required_if=Enabled true,gte=0"`
 }
 
 type DatabaseConfig struct {
-	Driver string
-	DSN    string
+	Driver string `config:"driver" env:"DB_DRIVER" validate:"required,oneof=mysql postgres sqlite"`
+	DSN    Secret `config:"dsn" env:"DB_DSN" validate:"required"`
+
+	// Options of the configured driver, added to the DSN unless it already
+	// sets them.
+	MySQL    MySQLConfig    `config:"mysql"`
+	Postgres PostgresConfig `config:"postgres"`
+	SQLite   SQLiteConfig   `config:"sqlite"`
+
+	Pool    PoolConfig    `config:"pool"`
+	Connect ConnectConfig `config:"connect"`
+
+	Replicas ReplicasConfig `config:"replicas"`
+
+	// SlowQueryThreshold logs queries taking longer as warnings; 0 disables.
+	SlowQueryThreshold time.Duration `config:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD" validate:"gte=0"`
+	// RedactParams logs SQL with placeholders instead of bound values.
+	RedactParams bool `config:"redact_params" env:"DB_REDACT_PARAMS"`
+	// QueryBudget is how many queries a request may run before a warning
+	// about a likely N+1 query is logged; 0 disables.
+	QueryBudget int `config:"query_budget" env:"DB_QUERY_BUDGET" validate:"gte=0"`
+
+	// Migrate is how the schema is brought up to date on startup:
+	// "versioned" applies pending migrations, "auto" runs GORM's
+	// AutoMigrate on the models (meant for tests) and "none" leaves it to
+	// `migrate up`.
+	Migrate string `config:"migrate" env:"DB_MIGRATE" validate:"oneof=versioned auto none"`
+	// MigrateLockTimeout is how long to wait for another instance that is
+	// migrating the same database.
+	MigrateLockTimeout time.Duration `config:"migrate_lock_timeout" env:"DB_MIGRATE_LOCK_TIMEOUT" validate:"gt=0"`
+}
+
+// PoolConfig sizes the connection pool. A zero value keeps database/sql's
+// default: unlimited open connections, 2 idle ones, and no expiry.
+type PoolConfig struct {
+	MaxOpenConns int `config:"max_open_conns" env:"DB_MAX_OPEN_CONNS" validate:"gte=0"`
+	MaxIdleConns int `config:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" validate:"gte=0"`
+	// ConnMaxLifetime closes connections this old, e.g. to follow a
+	// failover or rebalance across a load balancer.
+	ConnMaxLifetime time.Duration `config:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" validate:"gte=0"`
+	// ConnMaxIdleTime closes connections idle this long.
+	ConnMaxIdleTime time.Duration `config:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" validate:"gte=0"`
+}
+
+// ConnectConfig retries the first connection while the database is not
+// reachable yet, e.g. when it starts alongside the server.
+type ConnectConfig struct {
+	// Timeout is how long to keep retrying; 0 tries once.
+	Timeout time.Duration `config:"timeout" env:"DB_CONNECT_TIMEOUT" validate:"gte=0"`
+	// InitialBackoff is the wait after the first failure, doubled after
+	// each further one up to MaxBackoff.
+	InitialBackoff time.Duration `config:"initial_backoff" env:"DB_CONNECT_INITIAL_BACKOFF" validate:"gt=0"`
+	MaxBackoff     time.Duration `config:"max_backoff" env:"DB_CONNECT_MAX_BACKOFF" validate:"gt=0"`
 }
 
-func New() *Config {
+// ReplicasConfig sends reads to read replicas of the database, and writes
+// to the primary.
+type ReplicasConfig struct {
+	// DSNs of the replicas, which share the driver, options and pool
+	// settings of the primary.
+	DSNs []Secret `config:"dsns" env:"DB_REPLICA_DSNS"`
+	// Policy picks the replica of each read: "round_robin", "random" or
+	// "least_connections" (fewest connections in use).
+	Policy string `config:"policy" env:"DB_REPLICA_POLICY" validate:"oneof=round_robin random least_connections"`
+	// StickyWindow sends a client's reads to the primary for this long
+	// after it writes, so it reads its own writes despite replication lag;
+	// 0 disables.
+	StickyWindow time.Duration `config:"sticky_window" env:"DB_REPLICA_STICKY_WINDOW" validate:"gte=0"`
+	// HealthInterval is how often replicas are pinged. Reads skip
+	// unhealthy replicas, and go to the primary when none is healthy.
+	HealthInterval time.Duration `config:"health_interval" env:"DB_REPLICA_HEALTH_INTERVAL" validate:"gt=0"`
+}
+
+// MySQLConfig holds the MySQL connection options.
+type MySQLConfig struct {
+	// TLS is "true", "false", "skip-verify" or "preferred".
+	TLS string `config:"tls" env:"DB_MYSQL_TLS" validate:"omitempty,oneof=true false skip-verify preferred"`
+	// TimeZone is the location DATETIME values are read and written in.
+	TimeZone string `config:"timezone" env:"DB_MYSQL_TIMEZONE" validate:"omitempty,timezone"`
+}
+
+// PostgresConfig holds the PostgreSQL connection options.
+type PostgresConfig struct {
+	SSLMode string `config:"sslmode" env:"DB_POSTGRES_SSLMODE" validate:"omitempty,oneof=disable allow prefer require verify-ca verify-full"`
+	// SearchPath lists the schemas searched for unqualified names, e.g.
+	// "app,public".
+	SearchPath string `config:"search_path" env:"DB_POSTGRES_SEARCH_PATH"`
+	// TimeZone is the session time zone, e.g. "UTC".
+	TimeZone string `config:"timezone" env:"DB_POSTGRES_TIMEZONE" validate:"omitempty,timezone"`
+}
+
+// SQLiteConfig holds the SQLite connection options.
+type SQLiteConfig struct {
+	// BusyTimeout is how long a statement waits for a lock held by another
+	// connection before failing with "database is locked"; 0 fails at once.
+	BusyTimeout time.Duration `config:"busy_timeout" env:"DB_SQLITE_BUSY_TIMEOUT" validate:"gte=0"`
+}
+
+// TracingConfig exports OpenTelemetry traces of requests, parameter
+// binding, service calls and database queries. Incoming W3C traceparent
+// headers are continued rather than starting a new trace.
+type TracingConfig struct {
+	Enabled bool `config:"enabled" env:"TRACING_ENABLED"`
+	// Exporter is "otlp" (OTLP over HTTP to Endpoint), "stdout", or
+	// "file" (one JSON span per line, appended to Path) for offline use.
+	Exporter string `config:"exporter" env:"TRACING_EXPORTER" validate:"oneof=otlp stdout file"`
+	// Endpoint is the URL of the OTLP collector, e.g.
+	// "http://localhost:4318"; "/v1/traces" is appended to its path.
+	Endpoint string `config:"endpoint" env:"TRACING_ENDPOINT" validate:"required_if=Exporter otlp,omitempty,endpoint"`
+	Path     string `config:"path" env:"TRACING_FILE_PATH" validate:"required_if=Exporter file"`
+
+	ServiceName string `config:"service_name" env:"TRACING_SERVICE_NAME" validate:"required"`
+	// SampleRatio is the fraction of new traces recorded; traces started
+	// upstream follow the caller's sampling decision.
+	SampleRatio float64 `config:"sample_ratio" env:"TRACING_SAMPLE_RATIO" validate:"gte=0,lte=1"`
+}
+
+// HealthConfig tunes the checks behind the /livez and /readyz probes.
+type HealthConfig struct {
+	// Timeout bounds each check, such as the database ping.
+	Timeout time.Duration `config:"timeout" env:"HEALTH_TIMEOUT" validate:"gt=0"`
+	// CacheTTL reuses check results for this long, so frequent probes do
+	// not hammer the database; 0 runs the checks on every probe.
+	CacheTTL time.Duration `config:"cache_ttl" env:"HEALTH_CACHE_TTL" validate:"gte=0"`
+	// MinFreeDiskMB fails readiness when the file system holding a SQLite
+	// database has less space available; 0 disables the check.
+	MinFreeDiskMB int `config:"min_free_disk_mb" env:"HEALTH_MIN_FREE_DISK_MB" validate:"gte=0"`
+}
+
+// Default returns the built-in configuration used when no file or
+// environment variable overrides a value.
+func Default() *Config {
 	return &Config{
+		Env: "dev",
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
+			Port: "8080",
+			UnixSocket: UnixSocketConfig{
+				Mode: "0660",
+			},
+			CORS: CORSConfig{
+				AllowedOrigins: []string{"*"},
+			},
+			RateLimit: RateLimitConfig{
+				Enabled:           false,
+				RequestsPerSecond: 10,
+				Burst:             20,
+			},
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   30 * time.Second,
+			TLS: TLSConfig{
+				ClientAuth: "none",
+				MinVersion: "1.2",
+			},
+			Admin: AdminConfig{
+				Host: "127.0.0.1",
+				Port: "9090",
+			},
+			Metrics: MetricsConfig{
+				Enabled: true,
+			},
+			Debug: DebugConfig{
+				MaxProfileDuration: 60 * time.Second,
+			},
 		},
 		Database: DatabaseConfig{
-			Driver: getEnv("DB_DRIVER", "sqlite"),
-			DSN:    getEnv("DB_DSN", "test.db"),
+			Driver:             "sqlite",
+			DSN:                "test.db",
+			SlowQueryThreshold: 200 * time.Millisecond,
+			RedactParams:       true,
+			QueryBudget:        20,
+			Migrate:            "versioned",
+			MigrateLockTimeout: time.Minute,
+			Postgres: PostgresConfig{
+				SSLMode:  "prefer",
+				TimeZone: "UTC",
+			},
+			SQLite: SQLiteConfig{
+				BusyTimeout: 5 * time.Second,
+			},
+			Pool: PoolConfig{
+				MaxOpenConns:    25,
+				MaxIdleConns:    10,
+				ConnMaxLifetime: 30 * time.Minute,
+				ConnMaxIdleTime: 5 * time.Minute,
+			},
+			Connect: ConnectConfig{
+				Timeout:        30 * time.Second,
+				InitialBackoff: 500 * time.Millisecond,
+				MaxBackoff:     10 * time.Second,
+			},
+			Replicas: ReplicasConfig{
+				Policy:         "round_robin",
+				StickyWindow:   5 * time.Second,
+				HealthInterval: 5 * time.Second,
+			},
 		},
 		Log: LogConfig{
-			Level:  getEnv("LOG_LEVEL", "info"),
-			Format: getEnv("LOG_FORMAT", "pretty"),
+			Level:  "info",
+			Format: "pretty",
+			Console: ConsoleLogConfig{
+				Enabled: true,
+			},
+			File: FileLogConfig{
+				Path:           "logs/app.log",
+				Format:         "json",
+				MaxSizeMB:      100,
+				RotateInterval: 24 * time.Hour,
+				MaxAgeDays:     28,
+				MaxBackups:     7,
+				Compress:       true,
+			},
+			Redact: RedactConfig{
+				Enabled: true,
+				Fields:  []string{"password", "token", "secret", "authorization", "api_key", "dsn"},
+			},
+			Sampling: LogSamplingConfig{
+				Enabled:    true,
+				Period:     time.Second,
+				Burst:      100,
+				Thereafter: 100,
+			},
+			Access: AccessLogConfig{
+				Enabled:   true,
+				SkipPaths: []string{"/health", "/ready", "/livez", "/readyz"},
+			},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "otlp",
+			Endpoint:    "http://localhost:4318",
+			Path:        "logs/traces.json",
+			ServiceName: "gin-template",
+			SampleRatio: 1,
+		},
+		Health: HealthConfig{
+			Timeout:       2 * time.Second,
+			CacheTTL:      2 * time.Second,
+			MinFreeDiskMB: 100,
 		},
 	}
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Load builds the configuration in layers: built-in defaults, the config
+// file at path, the per-environment overlay next to it, the encrypted
+// secrets file named by SECRETS_FILE and finally environment variables
+// (including their "_FILE" variants). The result is validated before it is
+// returned.
+//
+// An empty path skips the file layers. The overlay for "config.yaml" in
+// environment "prod" is "config.prod.yaml"; it is optional. The environment
+// is taken from APP_ENV, falling back to the `env` key of the base file.
+func Load(path string) (*Config, error) {
+	cfg, _, err := Resolve(path)
+	if err != nil {
+		return nil, err
 	}
-	return defaultValue
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Resolve merges the configuration layers like Load, without validating
+// the result, and reports which layer each value came from.
+func Resolve(path string) (*Config, Sources, error) {
+	cfg := Default()
+	sources := make(Sources)
+
+	if path != "" {
+		if err := loadFile(cfg, path, sources); err != nil {
+			return nil, nil, err
+		}
+
+		env := cfg.Env
+		if value := os.Getenv("APP_ENV"); value != "" {
+			env = value
+		}
+
+		overlay := overlayPath(path, env)
+		if _, err := os.Stat(overlay); err == nil {
+			if err := loadFile(cfg, overlay, sources); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if err := loadSecrets(cfg, sources); err != nil {
+		return nil, nil, err
+	}
+
+	if err := loadEnv(cfg, sources); err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, sources, nil
+}
+
+// overlayPath returns the per-environment overlay file for path,
+// e.g. "configs/config.yaml" -> "configs/config.prod.yaml".
+func overlayPath(path, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
 }
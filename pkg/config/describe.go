@@ -0,0 +1,65 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SourceDefault marks a value that no layer overrode.
+const SourceDefault = "default"
+
+// Sources records which layer set each config key, e.g.
+// "server.port" -> "env:PORT" or "log.level" -> "file:configs/config.yaml".
+type Sources map[string]string
+
+// Of returns the source of key, or SourceDefault if no layer set it.
+func (s Sources) Of(key string) string {
+	if source, ok := s[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Entry is one resolved configuration value, formatted for display.
+type Entry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	EnvVar string `json:"env,omitempty"`
+}
+
+// Describe lists every leaf value of cfg in declaration order together
+// with its source. Secrets are masked.
+func Describe(cfg *Config, sources Sources) []Entry {
+	all := fields(cfg)
+	entries := make([]Entry, 0, len(all))
+	for _, f := range all {
+		entries = append(entries, Entry{
+			Key:    f.Key,
+			Value:  formatValue(f.Value.Interface()),
+			Source: sources.Of(f.Key),
+			EnvVar: f.EnvVar,
+		})
+	}
+	return entries
+}
+
+func formatValue(v any) string {
+	switch value := v.(type) {
+	case Secret:
+		return value.String()
+	case string:
+		return value
+	case time.Duration:
+		return value.String()
+	case []string, []Secret, map[string]string:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
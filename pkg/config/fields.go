@@ -0,0 +1,42 @@
+package config
+
+import (
+	"reflect"
+)
+
+// field describes one leaf value of a Config.
+type field struct {
+	Key        string
+	EnvVar     string
+	Reloadable bool
+	Value      reflect.Value
+}
+
+// fields flattens cfg into its leaf values in declaration order, keyed by
+// their dotted config path (e.g. "server.cors.allowed_origins").
+func fields(cfg *Config) []field {
+	var out []field
+	collectFields(reflect.ValueOf(cfg).Elem(), "", false, &out)
+	return out
+}
+
+func collectFields(v reflect.Value, prefix string, reloadable bool, out *[]field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := joinKey(prefix, sf.Tag.Get("config"))
+		fieldReloadable := reloadable || sf.Tag.Get("reload") == "true"
+
+		if v.Field(i).Kind() == reflect.Struct && sf.Type != durationType {
+			collectFields(v.Field(i), key, fieldReloadable, out)
+			continue
+		}
+
+		*out = append(*out, field{
+			Key:        key,
+			EnvVar:     sf.Tag.Get("env"),
+			Reloadable: fieldReloadable,
+			Value:      v.Field(i),
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// levels holds the base log level and the per-component overrides applied
+// by the loggers returned from GetLogger. zerolog's global level is kept
+// at the most verbose of them so events reach the component hooks.
+var levels = struct {
+	sync.RWMutex
+	base       zerolog.Level
+	components map[string]zerolog.Level
+	// generation invalidates pending TTL reverts when a level changes again.
+	generation map[string]uint64
+}{
+	base:       zerolog.InfoLevel,
+	components: map[string]zerolog.Level{},
+	generation: map[string]uint64{},
+}
+
+// LogLevels returns the base level and the per-component overrides.
+func LogLevels() (base string, components map[string]string) {
+	levels.RLock()
+	defer levels.RUnlock()
+
+	components = make(map[string]string, len(levels.components))
+	for name, level := range levels.components {
+		components[name] = level.String()
+	}
+	return levels.base.String(), components
+}
+
+// ComponentLogLevel returns the level in effect for component: its
+// override if one is set, the base level otherwise.
+func ComponentLogLevel(component string) zerolog.Level {
+	levels.RLock()
+	defer levels.RUnlock()
+
+	if level, ok := levels.components[component]; ok {
+		return level
+	}
+	return levels.base
+}
+
+// SetComponentLogLevel changes the level of component at runtime, or the
+// base level when component is empty. A level of "" removes the component
+// override. With a positive ttl the previous level is restored once ttl
+// has passed, unless the level was changed again in the meantime.
+func SetComponentLogLevel(component, level string, ttl time.Duration) error {
+	var parsed zerolog.Level
+	if level != "" || component == "" {
+		var err error
+		if parsed, err = parseStrictLogLevel(level); err != nil {
+			return err
+		}
+	}
+
+	levels.Lock()
+	defer levels.Unlock()
+
+	previous, hadPrevious := levels.base, true
+	if component != "" {
+		previous, hadPrevious = levels.components[component]
+	}
+
+	switch {
+	case component == "":
+		levels.base = parsed
+	case level == "":
+		delete(levels.components, component)
+	default:
+		levels.components[component] = parsed
+	}
+	levels.generation[component]++
+	applyGlobalLevel()
+
+	if ttl > 0 {
+		generation := levels.generation[component]
+		time.AfterFunc(ttl, func() {
+			if revertLogLevel(component, generation, previous, hadPrevious) {
+				logger := GetLogger("config")
+				logger.Info().
+					Str("log_component", component).
+					Str("log_level", ComponentLogLevel(component).String()).
+					Msg("Log level reverted after TTL")
+			}
+		})
+	}
+	return nil
+}
+
+// revertLogLevel restores the level of component that was in effect
+// before a change with a TTL, unless the level changed again since.
+func revertLogLevel(component string, generation uint64, previous zerolog.Level, hadPrevious bool) bool {
+	levels.Lock()
+	defer levels.Unlock()
+
+	if levels.generation[component] != generation {
+		return false
+	}
+	switch {
+	case component == "":
+		levels.base = previous
+	case hadPrevious:
+		levels.components[component] = previous
+	default:
+		delete(levels.components, component)
+	}
+	levels.generation[component]++
+	applyGlobalLevel()
+	return true
+}
+
+// setLogLevels replaces the base level and every component override, e.g.
+// from LogConfig, cancelling pending TTL reverts.
+func setLogLevels(base zerolog.Level, components map[string]string) {
+	parsed := make(map[string]zerolog.Level, len(components))
+	for name, level := range components {
+		parsed[name] = parseLogLevel(level)
+	}
+
+	levels.Lock()
+	defer levels.Unlock()
+
+	levels.base = base
+	levels.components = parsed
+	for name := range levels.generation {
+		levels.generation[name]++
+	}
+	applyGlobalLevel()
+}
+
+// applyGlobalLevel sets zerolog's global level to the most verbose level
+// in use. Callers hold levels' lock.
+func applyGlobalLevel() {
+	lowest := levels.base
+	for _, level := range levels.components {
+		lowest = min(lowest, level)
+	}
+	zerolog.SetGlobalLevel(lowest)
+}
+
+// parseStrictLogLevel is parseLogLevel but rejects unknown levels instead
+// of falling back to info.
+func parseStrictLogLevel(level string) (zerolog.Level, error) {
+	if level == "" {
+		return zerolog.NoLevel, fmt.Errorf("log level is required")
+	}
+	parsed := parseLogLevel(level)
+	if parsed == zerolog.InfoLevel && strings.ToLower(level) != "info" {
+		return zerolog.NoLevel, fmt.Errorf("unknown log level %q", level)
+	}
+	return parsed, nil
+}
+
+// baseLevelHook discards events below the base level, for the global
+// logger, which zerolog's global level lets through while a component is
+// more verbose.
+type baseLevelHook struct{}
+
+func (baseLevelHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	levels.RLock()
+	base := levels.base
+	levels.RUnlock()
+	if level < base {
+		e.Discard()
+	}
+}
+
+// componentLevelHook discards events below the level of its component.
+type componentLevelHook string
+
+func (h componentLevelHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if level < ComponentLogLevel(string(h)) {
+		e.Discard()
+	}
+}
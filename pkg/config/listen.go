@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Network names returned by ParseListenAddress.
+const (
+	NetworkTCP     = "tcp"
+	NetworkUnix    = "unix"
+	NetworkSystemd = "systemd"
+)
+
+// ParseListenAddress splits a listen address into its network and address:
+//
+//	tcp://:8080, tcp://127.0.0.1:8080   TCP
+//	unix:///run/app/api.sock            Unix domain socket
+//	systemd                             every socket passed by systemd
+//	systemd://api                       the systemd socket named "api"
+//	                                    (FileDescriptorName=)
+func ParseListenAddress(addr string) (network, address string, err error) {
+	if addr == NetworkSystemd {
+		return NetworkSystemd, "", nil
+	}
+
+	network, address, ok := strings.Cut(addr, "://")
+	if !ok {
+		return "", "", fmt.Errorf("listen address %q must look like tcp://host:port, unix:///path or systemd", addr)
+	}
+
+	switch network {
+	case NetworkTCP:
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return "", "", fmt.Errorf("listen address %q: %w", addr, err)
+		}
+	case NetworkUnix:
+		if address == "" {
+			return "", "", fmt.Errorf("listen address %q: missing socket path", addr)
+		}
+	case NetworkSystemd:
+	default:
+		return "", "", fmt.Errorf("listen address %q: unsupported network %q", addr, network)
+	}
+	return network, address, nil
+}
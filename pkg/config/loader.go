@@ -0,0 +1,266 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// loadFile decodes the YAML or TOML file at path and applies it on top of cfg.
+func loadFile(cfg *Config, path string, sources Sources) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	values := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	if err := applyMap(reflect.ValueOf(cfg).Elem(), values, "", "file:"+path, sources); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyMap assigns decoded file values to the struct v, matching keys
+// against `config` tags and recording source for every value set. Unknown
+// keys are rejected so typos do not go unnoticed.
+func applyMap(v reflect.Value, values map[string]any, prefix, source string, sources Sources) error {
+	for key, raw := range values {
+		path := joinKey(prefix, key)
+
+		field, ok := fieldByKey(v, key)
+		if !ok {
+			return fmt.Errorf("unknown key %q", path)
+		}
+
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			nested, ok := raw.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s: expected a table, got %T", path, raw)
+			}
+			if err := applyMap(field, nested, path, source, sources); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := setValue(field, raw); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		sources[path] = source
+	}
+	return nil
+}
+
+// loadEnv overrides cfg with every non-empty environment variable named by
+// an `env` tag, or the file named by its "_FILE" variant.
+func loadEnv(cfg *Config, sources Sources) error {
+	return walkEnv(reflect.ValueOf(cfg).Elem(), "", sources)
+}
+
+func walkEnv(v reflect.Value, prefix string, sources Sources) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		field := v.Field(i)
+		path := joinKey(prefix, sf.Tag.Get("config"))
+
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			if err := walkEnv(field, path, sources); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := sf.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, err := lookupEnv(name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if value == "" {
+			continue
+		}
+		if err := setValue(field, value); err != nil {
+			return fmt.Errorf("%s (from %s): %w", path, name, err)
+		}
+		if os.Getenv(name+"_FILE") != "" {
+			name += "_FILE"
+		}
+		sources[path] = "env:" + name
+	}
+	return nil
+}
+
+// lookupEnv returns the value of the environment variable name or, when
+// name+"_FILE" is set instead, the contents of the file it points to. This
+// is how Docker and Kubernetes secrets are usually mounted.
+func lookupEnv(name string) (string, error) {
+	value := os.Getenv(name)
+	file := os.Getenv(name + "_FILE")
+	if file == "" {
+		return value, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("only one of %s and %s_FILE may be set", name, name)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read %s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// fieldByKey finds the field of struct v whose `config` tag equals key.
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("config") == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// setValue converts raw, either a decoded file value or an environment
+// string, into the type of field.
+func setValue(field reflect.Value, raw any) error {
+	if raw == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if field.Type() == durationType {
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("expected a duration string such as \"5s\", got %v", raw)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(fmt.Sprint(raw))
+	case reflect.Bool:
+		switch b := raw.(type) {
+		case bool:
+			field.SetBool(b)
+		default:
+			parsed, err := strconv.ParseBool(fmt.Sprint(raw))
+			if err != nil {
+				return fmt.Errorf("expected a boolean, got %v", raw)
+			}
+			field.SetBool(parsed)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(fmt.Sprint(raw), 10, 64)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %v", raw)
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(fmt.Sprint(raw), 10, 64)
+		if err != nil {
+			return fmt.Errorf("expected a non-negative integer, got %v", raw)
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(fmt.Sprint(raw), 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %v", raw)
+		}
+		field.SetFloat(parsed)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", field.Type())
+		}
+		var items []string
+		switch list := raw.(type) {
+		case []any:
+			for _, item := range list {
+				items = append(items, fmt.Sprint(item))
+			}
+		case string:
+			// Environment variables carry lists as comma-separated values.
+			for _, item := range strings.Split(list, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		default:
+			return fmt.Errorf("expected a list, got %v", raw)
+		}
+		// Set element by element so lists of string types such as Secret work
+		list := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			list.Index(i).SetString(item)
+		}
+		field.Set(list)
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map type %s", field.Type())
+		}
+		// Map entries are merged so an overlay only needs to list the keys
+		// it changes.
+		items := make(map[string]string)
+		for _, k := range field.MapKeys() {
+			items[k.String()] = field.MapIndex(k).String()
+		}
+		switch m := raw.(type) {
+		case map[string]any:
+			for k, item := range m {
+				items[k] = fmt.Sprint(item)
+			}
+		case string:
+			// Environment variables carry maps as "key=value,key=value".
+			for _, pair := range strings.Split(m, ",") {
+				k, item, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					return fmt.Errorf("expected key=value pairs, got %q", pair)
+				}
+				items[strings.TrimSpace(k)] = strings.TrimSpace(item)
+			}
+		default:
+			return fmt.Errorf("expected a table, got %v", raw)
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -11,34 +13,79 @@ import (
 
 // LogConfig represents logging configuration
 type LogConfig struct {
-	Level  string
-	Format string
+	Level  string `config:"level" env:"LOG_LEVEL" reload:"true" validate:"oneof=trace debug info warn warning error fatal panic disabled"`
+	Format string `config:"format" env:"LOG_FORMAT" reload:"true" validate:"oneof=pretty console json"`
+	// Levels overrides Level per component (the name passed to GetLogger),
+	// e.g. {middleware: debug, database: warn}.
+	Levels map[string]string `config:"levels" env:"LOG_LEVELS" reload:"true" validate:"dive,keys,required,endkeys,oneof=trace debug info warn warning error fatal panic disabled"`
+
+	// Console and File are the sinks log output is written to; each can
+	// have its own format and minimum level.
+	Console ConsoleLogConfig `config:"console"`
+	File    FileLogConfig    `config:"file"`
+
+	Redact   RedactConfig      `config:"redact"`
+	Sampling LogSamplingConfig `config:"sampling" reload:"true"`
+
+	Access AccessLogConfig `config:"access" reload:"true"`
+}
+
+// AccessLogConfig controls the per-request access log.
+type AccessLogConfig struct {
+	Enabled bool `config:"enabled" env:"ACCESS_LOG_ENABLED"`
+	// SkipPaths are request paths, such as probes, that are never logged.
+	SkipPaths []string `config:"skip_paths" env:"ACCESS_LOG_SKIP_PATHS"`
 }
 
-// SetupLogger initializes zerolog with configuration
+// logOutput is the writer behind every logger. SetupLogger swaps its sinks
+// atomically, so a config reload does not race with goroutines logging,
+// and loggers created earlier follow the new settings.
+var logOutput = newSwapWriter(os.Stderr)
+
+// rootLogger writes to the configured sinks without level filtering;
+// GetLogger derives the component loggers from it.
+var rootLogger = zerolog.New(logOutput).With().Timestamp().Logger()
+
+// installLogger points zerolog's globals at logOutput, once.
+var installLogger sync.Once
+
+// SetupLogger initializes zerolog with configuration, writing console
+// output to stdout
 func SetupLogger(cfg LogConfig) {
-	// Set log level
-	level := parseLogLevel(cfg.Level)
-	zerolog.SetGlobalLevel(level)
-
-	// Configure output format
-	if cfg.Format == "pretty" || cfg.Format == "console" {
-		// Pretty console output for development
-		log.Logger = log.Output(zerolog.ConsoleWriter{
-			Out:        os.Stdout,
-			TimeFormat: time.RFC3339,
-			NoColor:    false,
-		})
-	} else {
-		// JSON output for production
+	SetupLoggerOutput(cfg, os.Stdout)
+}
+
+// SetupLoggerOutput initializes zerolog like SetupLogger but writes console
+// output to out. CLI commands use it to keep logs on stderr, away from
+// their output. It may be called again, e.g. on config reload, while
+// other goroutines are logging.
+func SetupLoggerOutput(cfg LogConfig, out io.Writer) {
+	installLogger.Do(func() {
 		zerolog.TimeFieldFormat = time.RFC3339
-		log.Logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
-	}
+		zerolog.TimestampFieldName = "timestamp"
+		zerolog.LevelFieldName = "level"
+		zerolog.MessageFieldName = "message"
+
+		// The global level follows the most verbose component, so the
+		// global logger filters on the base level itself. zerolog.Ctx
+		// falls back to it for contexts without a request logger.
+		log.Logger = rootLogger.Hook(baseLevelHook{})
+		zerolog.DefaultContextLogger = &log.Logger
+	})
+
+	// Set the base and per-component log levels; runtime changes made
+	// through SetComponentLogLevel are discarded
+	setLogLevels(parseLogLevel(cfg.Level), cfg.Levels)
+	logSampler.configure(cfg.Sampling)
+
+	// Configure the console and file sinks, behind PII redaction
+	logOutput.swap(redactWriter(cfg.Redact, logWriter(cfg, out)))
+}
 
-	// Set some global configurations
-	zerolog.TimestampFieldName = "timestamp"
-	zerolog.LevelFieldName = "level"
-	zerolog.MessageFieldName = "message"
+// SetLogLevel changes the base log level at runtime. Unlike the level in
+// LogConfig, an unknown level is an error rather than falling back to info.
+func SetLogLevel(level string) error {
+	return SetComponentLogLevel("", level, 0)
 }
 
 // parseLogLevel converts string to zerolog.Level
@@ -65,7 +112,9 @@ func parseLogLevel(level string) zerolog.Level {
 	}
 }
 
-// GetLogger returns a new logger with component context
+// GetLogger returns a new logger with component context. It logs at the
+// component's level from LogConfig.Levels or SetComponentLogLevel, which
+// may be changed while the logger is in use, and applies LogConfig.Sampling.
 func GetLogger(component string) zerolog.Logger {
-	return log.With().Str("component", component).Logger()
+	return rootLogger.With().Str("component", component).Logger().Hook(componentLevelHook(component), logSampler)
 }
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Provider holds the live configuration. Readers call Get on every use so
+// they observe reloads; the pointer swap makes each reload atomic.
+type Provider struct {
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex
+	subscribers []func(old, new *Config)
+}
+
+// NewProvider returns a Provider serving cfg.
+func NewProvider(cfg *Config) *Provider {
+	p := &Provider{}
+	p.current.Store(cfg)
+	return p
+}
+
+// Get returns the current configuration. The returned value must not be
+// modified.
+func (p *Provider) Get() *Config {
+	return p.current.Load()
+}
+
+// Subscribe registers fn to be called after every reload that changed at
+// least one reloadable value.
+func (p *Provider) Subscribe(fn func(old, new *Config)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subscribers = append(p.subscribers, fn)
+}
+
+// Apply copies the reloadable values of next into the current
+// configuration, swaps the result in and notifies subscribers. It returns
+// the keys that were applied and the keys that changed but need a restart
+// to take effect. next must already be validated.
+func (p *Provider) Apply(next *Config) (changed, ignored []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	old := p.current.Load()
+	merged := *old
+
+	oldFields := fields(old)
+	nextFields := fields(next)
+	mergedFields := fields(&merged)
+
+	for i, f := range oldFields {
+		if reflect.DeepEqual(f.Value.Interface(), nextFields[i].Value.Interface()) {
+			continue
+		}
+		if !f.Reloadable {
+			ignored = append(ignored, f.Key)
+			continue
+		}
+		mergedFields[i].Value.Set(nextFields[i].Value)
+		changed = append(changed, f.Key)
+	}
+
+	if len(changed) == 0 {
+		return nil, ignored
+	}
+
+	p.current.Store(&merged)
+	for _, fn := range p.subscribers {
+		fn(old, &merged)
+	}
+	return changed, ignored
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// RedactConfig masks personal data and credentials in every log event
+// before it reaches a sink.
+type RedactConfig struct {
+	Enabled bool `config:"enabled" env:"LOG_REDACT_ENABLED"`
+	// Fields are field names, at any depth, whose values are masked, in
+	// addition to fields of types registered with RegisterSensitive.
+	Fields []string `config:"fields" env:"LOG_REDACT_FIELDS"`
+	// Patterns are extra regular expressions masked inside string values,
+	// in addition to e-mail addresses, phone numbers and bearer tokens.
+	Patterns []string `config:"patterns" env:"LOG_REDACT_PATTERNS" validate:"dive,regexp"`
+}
+
+// redactedValue replaces masked values in log output.
+const redactedValue = "[REDACTED]"
+
+// builtinRedactPatterns match e-mail addresses, phone numbers and bearer
+// tokens wherever they appear in a string value.
+var builtinRedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	regexp.MustCompile(`(?:\+\d{1,3}[\s-]?)?\(?\b\d{3}\)?[\s.-]?\d{3}[\s.-]?\d{4}\b`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`),
+}
+
+// sensitiveFields holds the lower-cased field names registered through
+// RegisterSensitive.
+var sensitiveFields sync.Map
+
+// RegisterSensitive marks the fields of the given struct values tagged
+// `log:"sensitive"` so their values are masked wherever they appear in
+// logs, e.g. when a model is logged with Interface:
+//
+//	type User struct {
+//		Email string `json:"email" log:"sensitive"`
+//	}
+//
+// The field is matched by its json tag name, its form tag name, or else
+// its Go name.
+func RegisterSensitive(values ...any) {
+	for _, v := range values {
+		t := reflect.TypeOf(v)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			continue
+		}
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Tag.Get("log") != "sensitive" {
+				continue
+			}
+			sensitiveFields.Store(strings.ToLower(fieldName(field)), struct{}{})
+		}
+	}
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// redactor masks sensitive values in the JSON events zerolog writes.
+type redactor struct {
+	w        io.Writer
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// redactWriter wraps w with the redaction configured in cfg, or returns w
+// unchanged when redaction is disabled.
+func redactWriter(cfg RedactConfig, w io.Writer) io.Writer {
+	if !cfg.Enabled {
+		return w
+	}
+
+	r := &redactor{
+		w:        w,
+		fields:   make(map[string]bool, len(cfg.Fields)),
+		patterns: slices.Clone(builtinRedactPatterns),
+	}
+	for _, name := range cfg.Fields {
+		r.fields[strings.ToLower(name)] = true
+	}
+	for _, pattern := range cfg.Patterns {
+		// Patterns are validated with the configuration.
+		if re, err := regexp.Compile(pattern); err == nil {
+			r.patterns = append(r.patterns, re)
+		}
+	}
+	return r
+}
+
+func (r *redactor) Write(p []byte) (int, error) {
+	if _, err := r.w.Write(r.redact(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (r *redactor) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	lw, ok := r.w.(zerolog.LevelWriter)
+	if !ok {
+		return r.Write(p)
+	}
+	if _, err := lw.WriteLevel(level, r.redact(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// redact masks the values of sensitive fields and pattern matches inside
+// the other string values of a JSON event. Keys and non-string values are
+// left alone so timestamps, sizes and durations are not mistaken for phone
+// numbers.
+func (r *redactor) redact(event []byte) []byte {
+	out := make([]byte, 0, len(event))
+	maskNext := false
+	for i := 0; i < len(event); {
+		c := event[i]
+		switch {
+		case c == '"':
+			end := stringEnd(event, i)
+			token := event[i:end]
+			switch {
+			case isKey(event, end):
+				maskNext = r.sensitive(strings.ToLower(strings.Trim(string(token), `"`)))
+				out = append(out, token...)
+			case maskNext:
+				out = append(out, `"`+redactedValue+`"`...)
+				maskNext = false
+			default:
+				out = append(out, r.maskString(token)...)
+			}
+			i = end
+		case maskNext && c != ':' && c != ' ':
+			// A number, literal, object or array under a sensitive key
+			out = append(out, `"`+redactedValue+`"`...)
+			maskNext = false
+			i = valueEnd(event, i)
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	return out
+}
+
+// stringEnd returns the index just past the JSON string starting at i.
+func stringEnd(data []byte, i int) int {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(data)
+}
+
+// isKey reports whether the JSON string ending before i is an object key.
+func isKey(data []byte, i int) bool {
+	for ; i < len(data); i++ {
+		if data[i] != ' ' {
+			return data[i] == ':'
+		}
+	}
+	return false
+}
+
+// valueEnd returns the index just past the JSON value starting at i.
+func valueEnd(data []byte, i int) int {
+	if data[i] != '{' && data[i] != '[' {
+		for ; i < len(data); i++ {
+			if strings.IndexByte(",}] \n", data[i]) >= 0 {
+				return i
+			}
+		}
+		return i
+	}
+
+	depth := 0
+	for i < len(data) {
+		switch data[i] {
+		case '"':
+			i = stringEnd(data, i)
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return i
+}
+
+func (r *redactor) sensitive(key string) bool {
+	if r.fields[key] {
+		return true
+	}
+	_, ok := sensitiveFields.Load(key)
+	return ok
+}
+
+// maskString applies the patterns to a JSON string literal, so values
+// quoted inside messages (e.g. a rejected request body) are masked too.
+func (r *redactor) maskString(value []byte) []byte {
+	for _, re := range r.patterns {
+		value = re.ReplaceAll(value, []byte(redactedValue))
+	}
+	return value
+}
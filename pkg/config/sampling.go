@@ -0,0 +1,166 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// LogSamplingConfig limits how often the same event is logged, so a flood
+// of identical events (e.g. binding failures under load) cannot overwhelm
+// the log pipeline. Events are grouped by level and message: within each
+// Period the first Burst events are logged, then one in Thereafter.
+//
+// Dropped events are counted (see DroppedLogEvents) and summarised in a
+// warning at the end of the period.
+type LogSamplingConfig struct {
+	Enabled bool          `config:"enabled" env:"LOG_SAMPLING_ENABLED"`
+	Period  time.Duration `config:"period" env:"LOG_SAMPLING_PERIOD" validate:"gt=0"`
+	Burst   int           `config:"burst" env:"LOG_SAMPLING_BURST" validate:"gte=0"`
+	// Thereafter logs every Thereafter-th event once the burst is used up;
+	// 0 drops them all until the next period.
+	Thereafter int `config:"thereafter" env:"LOG_SAMPLING_THEREAFTER" validate:"gte=0"`
+}
+
+// droppedEvents counts every event dropped by sampling since start.
+var droppedEvents atomic.Uint64
+
+// DroppedLogEvents returns how many log events sampling has dropped since
+// the process started.
+func DroppedLogEvents() uint64 {
+	return droppedEvents.Load()
+}
+
+type samplingKey struct {
+	level zerolog.Level
+	msg   string
+}
+
+// sampler is the zerolog hook applying LogSamplingConfig to the loggers
+// returned by GetLogger.
+type sampler struct {
+	mu     sync.Mutex
+	cfg    LogSamplingConfig
+	start  time.Time
+	counts map[samplingKey]int
+	// dropped counts the events dropped in the current period by message.
+	dropped map[string]int
+	// flushTimer reports dropped at the end of the period.
+	flushTimer *time.Timer
+}
+
+var logSampler = &sampler{}
+
+// configure replaces the sampling settings and starts a new period,
+// reporting the drops of the current one.
+func (s *sampler) configure(cfg LogSamplingConfig) {
+	s.mu.Lock()
+	summary := s.takeDropped()
+	s.cfg = cfg
+	s.start = time.Now()
+	s.counts = make(map[samplingKey]int)
+	s.mu.Unlock()
+
+	if summary != nil {
+		reportDropped(summary)
+	}
+}
+
+// flush reports the drops not reported yet.
+func (s *sampler) flush() {
+	s.mu.Lock()
+	summary := s.takeDropped()
+	s.mu.Unlock()
+
+	if summary != nil {
+		reportDropped(summary)
+	}
+}
+
+// takeDropped returns and resets the drops not reported yet, or nil if
+// there are none. Callers hold s.mu.
+func (s *sampler) takeDropped() map[string]int {
+	if s.flushTimer != nil {
+		s.flushTimer.Stop()
+		s.flushTimer = nil
+	}
+	if len(s.dropped) == 0 {
+		s.dropped = make(map[string]int)
+		return nil
+	}
+	summary := s.dropped
+	s.dropped = make(map[string]int)
+	return summary
+}
+
+// FlushLogSampling logs the summary of the events sampling dropped in the
+// current period now, rather than at its end. The server calls it before
+// closing the log file.
+func FlushLogSampling() {
+	logSampler.flush()
+}
+
+func (s *sampler) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	// Already discarded by the component level
+	if !e.Enabled() {
+		return
+	}
+
+	keep, summary := s.sample(samplingKey{level: level, msg: msg})
+	if summary != nil {
+		reportDropped(summary)
+	}
+	if !keep {
+		droppedEvents.Add(1)
+		e.Discard()
+	}
+}
+
+// sample records one event and reports whether to keep it. When a new
+// period starts before the previous one's drops were reported, it also
+// returns them.
+func (s *sampler) sample(key samplingKey) (keep bool, summary map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.cfg.Enabled {
+		return true, nil
+	}
+
+	now := time.Now()
+	if now.Sub(s.start) >= s.cfg.Period {
+		summary = s.takeDropped()
+		clear(s.counts)
+		s.start = now
+	}
+
+	s.counts[key]++
+	n := s.counts[key]
+	keep = n <= s.cfg.Burst || (s.cfg.Thereafter > 0 && (n-s.cfg.Burst)%s.cfg.Thereafter == 0)
+	if !keep {
+		// The summary is due at the end of the period even if no event
+		// follows
+		if s.flushTimer == nil {
+			s.flushTimer = time.AfterFunc(s.start.Add(s.cfg.Period).Sub(now), s.flush)
+		}
+		s.dropped[key.msg]++
+	}
+	return keep, summary
+}
+
+// reportDropped logs how many events sampling dropped, by message. It
+// writes to the global logger, which is not sampled.
+func reportDropped(dropped map[string]int) {
+	total := 0
+	for _, n := range dropped {
+		total += n
+	}
+	log.Warn().
+		Str("component", "logging").
+		Int("dropped", total).
+		Interface("messages", dropped).
+		Msg("Log events dropped by sampling")
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted is what a Secret prints instead of its value.
+const redacted = "******"
+
+// Secret is a configuration string that must never appear in logs or API
+// output. It redacts itself when formatted, logged or marshalled; call
+// Value to get the real contents.
+type Secret string
+
+// Value returns the unredacted secret.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer. An empty secret prints as empty so it is
+// still obvious when a value is missing.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString implements fmt.GoStringer so %#v is redacted too.
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// MarshalText implements encoding.TextMarshaler; encoding/json and zerolog
+// both use it.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// secretsFilePrefix marks the format version of an encrypted secrets file.
+const secretsFilePrefix = "v1:"
+
+// EncryptSecrets seals plaintext (a YAML document using the same keys as
+// the config file) with AES-256-GCM under key and returns the contents of
+// a secrets file.
+func EncryptSecrets(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return []byte(secretsFilePrefix + base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+// DecryptSecrets opens a secrets file produced by EncryptSecrets.
+func DecryptSecrets(key, data []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(string(data)), secretsFilePrefix)
+	if !ok {
+		return nil, errors.New("unrecognised secrets file format")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode secrets file: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("secrets file is truncated")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("decrypt secrets file: wrong key or corrupted file")
+	}
+	return plaintext, nil
+}
+
+// ParseSecretsKey decodes a base64 encoded 32-byte key.
+func ParseSecretsKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("secrets key is not valid base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secrets key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// loadSecrets applies the encrypted secrets file named by SECRETS_FILE,
+// decrypted with the key in SECRETS_KEY (or SECRETS_KEY_FILE).
+func loadSecrets(cfg *Config, sources Sources) error {
+	path, err := lookupEnv("SECRETS_FILE")
+	if err != nil || path == "" {
+		return err
+	}
+
+	encodedKey, err := lookupEnv("SECRETS_KEY")
+	if err != nil {
+		return err
+	}
+	if encodedKey == "" {
+		return errors.New("SECRETS_FILE is set but SECRETS_KEY is not")
+	}
+	key, err := ParseSecretsKey(encodedKey)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read secrets file: %w", err)
+	}
+	plaintext, err := DecryptSecrets(key, data)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]any)
+	if err := yaml.Unmarshal(plaintext, &values); err != nil {
+		return fmt.Errorf("parse secrets file: %w", err)
+	}
+	if err := applyMap(reflect.ValueOf(cfg).Elem(), values, "", "secrets:"+path, sources); err != nil {
+		return fmt.Errorf("secrets file: %w", err)
+	}
+	return nil
+}
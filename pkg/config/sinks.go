@@ -0,0 +1,236 @@
+package config
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// ConsoleLogConfig controls log output to the console (stdout for the
+// server, stderr for other commands), formatted according to
+// LogConfig.Format.
+type ConsoleLogConfig struct {
+	Enabled bool `config:"enabled" env:"LOG_CONSOLE_ENABLED"`
+	// Level is the minimum level written to the console, on top of the
+	// logger levels; empty writes everything.
+	Level string `config:"level" env:"LOG_CONSOLE_LEVEL" validate:"omitempty,oneof=trace debug info warn warning error fatal panic"`
+}
+
+// FileLogConfig controls log output to a file rotated by size and time.
+type FileLogConfig struct {
+	Enabled bool   `config:"enabled" env:"LOG_FILE_ENABLED"`
+	Path    string `config:"path" env:"LOG_FILE_PATH" validate:"required_if=Enabled true"`
+	Format  string `config:"format" env:"LOG_FILE_FORMAT" validate:"oneof=pretty console json"`
+	// Level is the minimum level written to the file; empty writes
+	// everything.
+	Level string `config:"level" env:"LOG_FILE_LEVEL" validate:"omitempty,oneof=trace debug info warn warning error fatal panic"`
+
+	// MaxSizeMB rotates the file once it reaches this size.
+	MaxSizeMB int `config:"max_size_mb" env:"LOG_FILE_MAX_SIZE_MB" validate:"gt=0"`
+	// RotateInterval also rotates the file at every multiple of this
+	// duration, e.g. at midnight UTC for 24h; 0 rotates by size only.
+	RotateInterval time.Duration `config:"rotate_interval" env:"LOG_FILE_ROTATE_INTERVAL" validate:"gte=0"`
+	// MaxAgeDays removes rotated files older than this; 0 keeps them.
+	MaxAgeDays int `config:"max_age_days" env:"LOG_FILE_MAX_AGE_DAYS" validate:"gte=0"`
+	// MaxBackups is how many rotated files to keep; 0 keeps all.
+	MaxBackups int  `config:"max_backups" env:"LOG_FILE_MAX_BACKUPS" validate:"gte=0"`
+	Compress   bool `config:"compress" env:"LOG_FILE_COMPRESS"`
+}
+
+// logFile is the open log file, kept across SetupLogger calls (e.g. on
+// config reload) so it is only reopened when its settings change.
+var logFile struct {
+	sync.Mutex
+	cfg    FileLogConfig
+	writer *lumberjack.Logger
+	// stopRotation ends the time-based rotation of writer.
+	stopRotation chan struct{}
+}
+
+// CloseLogFile flushes and closes the log file, if one is open. Events
+// logged afterwards are not written to the file. The server calls it
+// after its last log line.
+func CloseLogFile() error {
+	logFile.Lock()
+	defer logFile.Unlock()
+
+	return closeLogFileLocked()
+}
+
+// closeLogFileLocked closes the log file with logFile locked.
+func closeLogFileLocked() error {
+	if logFile.writer == nil {
+		return nil
+	}
+	if logFile.stopRotation != nil {
+		close(logFile.stopRotation)
+		logFile.stopRotation = nil
+	}
+	err := logFile.writer.Close()
+	logFile.writer = nil
+	return err
+}
+
+// openLogFile returns the rotating writer for cfg, reusing the open one if
+// its settings did not change.
+func openLogFile(cfg FileLogConfig) *lumberjack.Logger {
+	logFile.Lock()
+	defer logFile.Unlock()
+
+	if logFile.writer != nil {
+		if logFile.cfg == cfg {
+			return logFile.writer
+		}
+		_ = closeLogFileLocked()
+	}
+
+	// lumberjack opens the file lazily on the first write.
+	logFile.cfg = cfg
+	logFile.writer = &lumberjack.Logger{
+		Filename:   cfg.Path,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxAge:     cfg.MaxAgeDays,
+		MaxBackups: cfg.MaxBackups,
+		Compress:   cfg.Compress,
+		LocalTime:  true,
+	}
+	if cfg.RotateInterval > 0 {
+		logFile.stopRotation = make(chan struct{})
+		go rotateLogFile(logFile.writer, cfg.RotateInterval, logFile.stopRotation)
+	}
+	return logFile.writer
+}
+
+// fileSink writes to a log file while it is the open one. lumberjack
+// would reopen a closed file on the next write, so events logged after
+// CloseLogFile, or after a reload replaced the file, are dropped instead.
+type fileSink struct {
+	w *lumberjack.Logger
+}
+
+func (f fileSink) Write(p []byte) (int, error) {
+	logFile.Lock()
+	defer logFile.Unlock()
+
+	if logFile.writer != f.w {
+		return len(p), nil
+	}
+	return f.w.Write(p)
+}
+
+// rotateLogFile rotates w at every multiple of interval until stop is
+// closed.
+func rotateLogFile(w *lumberjack.Logger, interval time.Duration, stop <-chan struct{}) {
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(interval).Add(interval).Sub(now))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// Rotating a closed writer would reopen it
+		logFile.Lock()
+		if logFile.writer == w {
+			_ = w.Rotate()
+		}
+		logFile.Unlock()
+	}
+}
+
+// logWriter combines the enabled sinks of cfg. console is where console
+// output goes.
+func logWriter(cfg LogConfig, console io.Writer) io.Writer {
+	var sinks []io.Writer
+	if cfg.Console.Enabled {
+		sinks = append(sinks, sink(formatWriter(cfg.Format, console, true), cfg.Console.Level))
+	}
+	if cfg.File.Enabled {
+		file := fileSink{openLogFile(cfg.File)}
+		sinks = append(sinks, sink(formatWriter(cfg.File.Format, file, false), cfg.File.Level))
+	} else {
+		_ = CloseLogFile()
+	}
+
+	switch len(sinks) {
+	case 0:
+		return io.Discard
+	case 1:
+		return sinks[0]
+	default:
+		return zerolog.MultiLevelWriter(sinks...)
+	}
+}
+
+// formatWriter returns out as is for JSON, or wrapped in a human readable
+// zerolog.ConsoleWriter for "pretty" and "console".
+func formatWriter(format string, out io.Writer, color bool) io.Writer {
+	if format == "pretty" || format == "console" {
+		return zerolog.ConsoleWriter{
+			Out:        out,
+			TimeFormat: time.RFC3339,
+			NoColor:    !color,
+		}
+	}
+	return out
+}
+
+// sink drops events below level before they reach w.
+func sink(w io.Writer, level string) io.Writer {
+	if level == "" {
+		return w
+	}
+	return &levelFilter{w: w, min: parseLogLevel(level)}
+}
+
+// levelFilter is a zerolog.LevelWriter writing only events at or above min.
+type levelFilter struct {
+	w   io.Writer
+	min zerolog.Level
+}
+
+func (f *levelFilter) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+func (f *levelFilter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < f.min {
+		return len(p), nil
+	}
+	return f.w.Write(p)
+}
+
+// swapWriter is a zerolog.LevelWriter passing events to a writer that can
+// be replaced while in use.
+type swapWriter struct {
+	current atomic.Pointer[zerolog.LevelWriter]
+}
+
+func newSwapWriter(w io.Writer) *swapWriter {
+	s := &swapWriter{}
+	s.swap(w)
+	return s
+}
+
+// swap makes w receive the following events.
+func (s *swapWriter) swap(w io.Writer) {
+	lw, ok := w.(zerolog.LevelWriter)
+	if !ok {
+		lw = zerolog.MultiLevelWriter(w)
+	}
+	s.current.Store(&lw)
+}
+
+func (s *swapWriter) Write(p []byte) (int, error) {
+	return (*s.current.Load()).Write(p)
+}
+
+func (s *swapWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	return (*s.current.Load()).WriteLevel(level, p)
+}
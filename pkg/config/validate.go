@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationError lists every configuration field that failed validation.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// Report fields by their config key (e.g. "server.port") rather than
+	// by Go field name.
+	v.RegisterTagNameFunc(func(sf reflect.StructField) string {
+		return sf.Tag.Get("config")
+	})
+
+	_ = v.RegisterValidation("port", func(fl validator.FieldLevel) bool {
+		port, err := strconv.Atoi(fl.Field().String())
+		return err == nil && port > 0 && port <= 65535
+	})
+
+	_ = v.RegisterValidation("listen", func(fl validator.FieldLevel) bool {
+		_, _, err := ParseListenAddress(fl.Field().String())
+		return err == nil
+	})
+
+	_ = v.RegisterValidation("filemode", func(fl validator.FieldLevel) bool {
+		mode, err := strconv.ParseUint(fl.Field().String(), 8, 32)
+		return err == nil && mode <= 0o777
+	})
+
+	_ = v.RegisterValidation("regexp", func(fl validator.FieldLevel) bool {
+		_, err := regexp.Compile(fl.Field().String())
+		return err == nil
+	})
+
+	_ = v.RegisterValidation("timezone", func(fl validator.FieldLevel) bool {
+		_, err := time.LoadLocation(fl.Field().String())
+		return err == nil
+	})
+
+	_ = v.RegisterValidation("endpoint", func(fl validator.FieldLevel) bool {
+		u, err := url.Parse(fl.Field().String())
+		return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+			u.RawQuery == "" && u.Fragment == ""
+	})
+
+	return v
+}
+
+// Validate checks cfg against the rules in its `validate` tags and returns
+// a *ValidationError describing every problem found.
+func (c *Config) Validate() error {
+	err := validate.Struct(c)
+	if err == nil {
+		return nil
+	}
+
+	fieldErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	problems := make([]string, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		problems = append(problems, describe(fe))
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// describe turns a validator error into "key: reason".
+func describe(fe validator.FieldError) string {
+	// Namespace is "Config.server.port"; drop the root type name.
+	key := fe.Namespace()
+	if _, rest, ok := strings.Cut(key, "."); ok {
+		key = rest
+	}
+
+	var reason string
+	switch fe.Tag() {
+	case "required":
+		reason = "is required"
+	case "oneof":
+		reason = fmt.Sprintf("must be one of [%s], got %q", fe.Param(), fmt.Sprint(fe.Value()))
+	case "port":
+		reason = fmt.Sprintf("must be a port number between 1 and 65535, got %q", fmt.Sprint(fe.Value()))
+	case "listen":
+		_, _, err := ParseListenAddress(fmt.Sprint(fe.Value()))
+		reason = err.Error()
+	case "filemode":
+		reason = fmt.Sprintf("must be an octal file mode such as 0660, got %q", fmt.Sprint(fe.Value()))
+	case "regexp":
+		reason = fmt.Sprintf("must be a valid regular expression, got %q", fmt.Sprint(fe.Value()))
+	case "timezone":
+		reason = fmt.Sprintf("must be a time zone such as UTC or Europe/Paris, got %q", fmt.Sprint(fe.Value()))
+	case "min":
+		reason = fmt.Sprintf("must be at least %s, got %v", fe.Param(), fe.Value())
+	case "max":
+		reason = fmt.Sprintf("must be at most %s, got %v", fe.Param(), fe.Value())
+	case "gt":
+		reason = fmt.Sprintf("must be greater than %s, got %v", fe.Param(), fe.Value())
+	case "gte":
+		reason = fmt.Sprintf("must be greater than or equal to %s, got %v", fe.Param(), fe.Value())
+	case "lte":
+		reason = fmt.Sprintf("must be less than or equal to %s, got %v", fe.Param(), fe.Value())
+	case "url":
+		reason = fmt.Sprintf("must be a URL, got %q", fmt.Sprint(fe.Value()))
+	case "endpoint":
+		reason = fmt.Sprintf("must be an http or https URL without query, such as http://localhost:4318, got %q", fmt.Sprint(fe.Value()))
+	case "required_if":
+		reason = "is required when " + strings.Replace(fe.Param(), " ", " is ", 1)
+	case "required_unless":
+		reason = "is required unless " + strings.Replace(fe.Param(), " ", " is ", 1)
+	default:
+		reason = fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+	return key + ": " + reason
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce coalesces the burst of events editors produce on save.
+const reloadDebounce = 200 * time.Millisecond
+
+// Watch reloads the configuration whenever the config file at path (or one
+// of its environment overlays) changes, or the process receives SIGHUP,
+// until ctx is done. Each reload is loaded and validated from scratch; a
+// reload that fails is logged and the running configuration is kept.
+//
+// With an empty path only SIGHUP triggers a reload.
+func Watch(ctx context.Context, path string, p *Provider) error {
+	logger := GetLogger("config")
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events chan fsnotify.Event
+	var watchErrs chan error
+	if path != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+
+		// Watch the directory rather than the file: editors and Kubernetes
+		// config maps replace files, which drops a watch on the file itself.
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			return err
+		}
+		events = watcher.Events
+		watchErrs = watcher.Errors
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			logger.Info().Msg("Received SIGHUP, reloading configuration")
+			reload(path, p)
+		case event := <-events:
+			if isConfigFile(path, event.Name) {
+				debounce.Reset(reloadDebounce)
+			}
+		case <-debounce.C:
+			logger.Info().Str("file", path).Msg("Config file changed, reloading configuration")
+			reload(path, p)
+		case err := <-watchErrs:
+			logger.Error().Err(err).Msg("Config file watcher error")
+		}
+	}
+}
+
+// reload loads the configuration again and applies it to p.
+func reload(path string, p *Provider) {
+	logger := GetLogger("config")
+
+	next, err := Load(path)
+	if err != nil {
+		logger.Error().Err(err).Msg("Configuration reload rejected")
+		return
+	}
+
+	changed, ignored := p.Apply(next)
+	if len(ignored) > 0 {
+		logger.Warn().Strs("keys", ignored).Msg("Changed settings require a restart and were not applied")
+	}
+	if len(changed) == 0 {
+		logger.Info().Msg("Configuration reloaded, no reloadable settings changed")
+		return
+	}
+	logger.Info().Strs("keys", changed).Msg("Configuration reloaded")
+}
+
+// isConfigFile reports whether name is the base config file or one of its
+// "<stem>.<env><ext>" overlays.
+func isConfigFile(path, name string) bool {
+	if filepath.Clean(name) == filepath.Clean(path) {
+		return true
+	}
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(filepath.Base(path), ext)
+	base := filepath.Base(name)
+	return filepath.Dir(name) == filepath.Dir(path) &&
+		strings.HasPrefix(base, stem+".") && strings.HasSuffix(base, ext)
+}
@@ -33,7 +33,7 @@ func NewUserController(userService *service.UserService) *UserController {
 // @Failure 500 {object} middleware.Response "Internal server error"
 // @Router /users [post]
 func (uc *UserController) CreateUser(c *gin.Context, req models.CreateUserRequest) {
-	user, err := uc.userService.CreateUser(&req)
+	user, err := uc.userService.CreateUser(c.Request.Context(), &req)
 	if err != nil {
 		middleware.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -65,7 +65,7 @@ func (uc *UserController) GetUser(c *gin.Context) {
 		return
 	}
 
-	user, err := uc.userService.GetUser(uint(id))
+	user, err := uc.userService.GetUser(c.Request.Context(), uint(id))
 	if err != nil {
 		middleware.ErrorResponse(c, http.StatusNotFound, err.Error())
 		return
@@ -88,7 +88,7 @@ func (uc *UserController) GetUser(c *gin.Context) {
 // @Failure 500 {object} middleware.Response "Internal server error"
 // @Router /users [get]
 func (uc *UserController) GetUsers(c *gin.Context, query models.GetUsersQuery) {
-	users, total, err := uc.userService.GetUsers(&query)
+	users, total, err := uc.userService.GetUsers(c.Request.Context(), &query)
 	if err != nil {
 		middleware.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -129,7 +129,7 @@ func (uc *UserController) UpdateUser(c *gin.Context, req models.UpdateUserReques
 		return
 	}
 
-	user, err := uc.userService.UpdateUser(uint(id), &req)
+	user, err := uc.userService.UpdateUser(c.Request.Context(), uint(id), &req)
 	if err != nil {
 		middleware.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -161,7 +161,7 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	err = uc.userService.DeleteUser(uint(id))
+	err = uc.userService.DeleteUser(c.Request.Context(), uint(id))
 	if err != nil {
 		middleware.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
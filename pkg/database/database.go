@@ -1,38 +1,90 @@
 package database
 
 import (
+	"context"
+	"fmt"
+
 	"gin-template/pkg/config"
+	"gin-template/pkg/metrics"
 	"gin-template/pkg/models"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-func New(cfg config.DatabaseConfig) (*gorm.DB, error) {
-	var db *gorm.DB
-	var err error
+// New connects to the database, retrying while it is not reachable,
+// migrates the schema as cfg.Migrate says and exports the connection pool
+// statistics as metrics.
+func New(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := Connect(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Migrate(ctx, db, cfg); err != nil {
+		return nil, err
+	}
 
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	metrics.RegisterDBStats("main", sqlDB)
+
+	return db, nil
+}
+
+// Open connects to the database without touching the schema or retrying.
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	cfg.Connect = config.ConnectConfig{}
+	return Connect(context.Background(), cfg)
+}
+
+// open connects to the database at dsn once.
+func open(ctx context.Context, cfg config.DatabaseConfig, dsn string) (*gorm.DB, error) {
+	gormCfg := &gorm.Config{Logger: newQueryLogger(cfg), DisableAutomaticPing: true}
+
+	var dialector gorm.Dialector
 	switch cfg.Driver {
 	case "mysql":
-		db, err = gorm.Open(mysql.Open(cfg.DSN), &gorm.Config{})
+		dialector = mysql.Open(dsn)
+	case "postgres":
+		dialector = postgres.Open(dsn)
 	case "sqlite":
-		db, err = gorm.Open(sqlite.Open(cfg.DSN), &gorm.Config{})
+		dialector = sqlite.Open(dsn)
 	default:
-		db, err = gorm.Open(sqlite.Open(cfg.DSN), &gorm.Config{})
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
 	}
 
+	db, err := gorm.Open(dialector, gormCfg)
 	if err != nil {
 		return nil, err
 	}
-
-	// 自动迁移模型
-	err = db.AutoMigrate(
-		&models.User{},
-	)
+	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	configurePool(sqlDB, cfg)
 
+	if err := db.Use(metricsPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := db.Use(tracingPlugin{}); err != nil {
+		return nil, err
+	}
 	return db, nil
 }
+
+// schema lists the models, in dependency order, for AutoMigrate. Changes
+// to them also need a migration.
+func schema() []any {
+	return []any{
+		&models.User{},
+	}
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gin-template/pkg/config"
+)
+
+// DSN returns cfg.DSN with the options of cfg.Driver added, leaving those
+// the DSN already sets untouched.
+func DSN(cfg config.DatabaseConfig) (string, error) {
+	dsn := cfg.DSN.Value()
+	switch cfg.Driver {
+	case "mysql":
+		var params [][2]string
+		if cfg.MySQL.TLS != "" {
+			params = append(params, [2]string{"tls", cfg.MySQL.TLS})
+		}
+		if cfg.MySQL.TimeZone != "" {
+			params = append(params, [2]string{"loc", cfg.MySQL.TimeZone})
+		}
+		return addQueryParams(dsn, params), nil
+	case "postgres":
+		params := [][2]string{
+			{"sslmode", cfg.Postgres.SSLMode},
+			{"search_path", cfg.Postgres.SearchPath},
+			{"TimeZone", cfg.Postgres.TimeZone},
+		}
+		if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+			return addURLParams(dsn, params)
+		}
+		return addKeywordParams(dsn, params), nil
+	case "sqlite":
+		if cfg.SQLite.BusyTimeout > 0 && !hasQueryParam(dsn, "_timeout") {
+			ms := strconv.FormatInt(cfg.SQLite.BusyTimeout.Milliseconds(), 10)
+			return addQueryParams(dsn, [][2]string{{"_busy_timeout", ms}}), nil
+		}
+		return dsn, nil
+	default:
+		return "", fmt.Errorf("unknown database driver %q: want mysql, postgres or sqlite", cfg.Driver)
+	}
+}
+
+// addQueryParams appends params missing from the "?key=value&..." part of
+// a MySQL or SQLite DSN.
+func addQueryParams(dsn string, params [][2]string) string {
+	var b strings.Builder
+	b.WriteString(dsn)
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	for _, param := range params {
+		if param[1] == "" || hasQueryParam(dsn, param[0]) {
+			continue
+		}
+		b.WriteString(sep + param[0] + "=" + url.QueryEscape(param[1]))
+		sep = "&"
+	}
+	return b.String()
+}
+
+// hasQueryParam reports whether the DSN's query sets key, or, for
+// "_timeout", its alias "_busy_timeout".
+func hasQueryParam(dsn, key string) bool {
+	_, query, ok := strings.Cut(dsn, "?")
+	if !ok {
+		return false
+	}
+	values, _ := url.ParseQuery(query)
+	if key == "_timeout" && values.Has("_busy_timeout") {
+		return true
+	}
+	return values.Has(key)
+}
+
+// addURLParams adds params missing from a postgres:// URL.
+func addURLParams(dsn string, params [][2]string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("parse database URL: %w", err)
+	}
+	query := u.Query()
+	for _, param := range params {
+		if param[1] == "" || hasKey(query, param[0]) {
+			continue
+		}
+		query.Set(param[0], param[1])
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// hasKey reports whether query sets key, ignoring case as PostgreSQL does
+// for setting names.
+func hasKey(query url.Values, key string) bool {
+	for k := range query {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// addKeywordParams adds params missing from a "host=... dbname=..." DSN.
+func addKeywordParams(dsn string, params [][2]string) string {
+	var b strings.Builder
+	b.WriteString(dsn)
+	for _, param := range params {
+		key := regexp.MustCompile(`(?i)(^|\s)` + regexp.QuoteMeta(param[0]) + `\s*=`)
+		if param[1] == "" || key.MatchString(dsn) {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(param[0] + "=" + quoteKeyword(param[1]))
+	}
+	return b.String()
+}
+
+// quoteKeyword quotes a keyword/value DSN value holding spaces, quotes or
+// backslashes.
+func quoteKeyword(value string) string {
+	if !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
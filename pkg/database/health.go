@@ -0,0 +1,42 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/health"
+
+	"gorm.io/gorm"
+)
+
+// RegisterHealthChecks registers the readiness checks of db: a ping and,
+// for a SQLite database file, the space left on its disk when minFreeDisk
+// is not 0.
+func RegisterHealthChecks(checks *health.Registry, db *gorm.DB, cfg config.DatabaseConfig, minFreeDisk uint64) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	checks.Register("database", health.Ping(sqlDB))
+
+	if path := sqliteFile(cfg); path != "" && minFreeDisk > 0 {
+		checks.Register("disk", health.DiskSpace(filepath.Dir(path), minFreeDisk))
+	}
+	return nil
+}
+
+// sqliteFile returns the database file named by a SQLite DSN such as
+// "test.db" or "file:test.db?cache=shared", or "" for other drivers and
+// in-memory databases.
+func sqliteFile(cfg config.DatabaseConfig) string {
+	if cfg.Driver != "sqlite" {
+		return ""
+	}
+	dsn := cfg.DSN.Value()
+	path, params, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	if path == "" || strings.Contains(path, ":memory:") || strings.Contains(params, "mode=memory") {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/requestid"
+	"gin-template/pkg/tracing"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// queryLogger is a gorm logger.Interface writing through zerolog, so SQL
+// logs follow LOG_LEVEL and LOG_FORMAT and carry the request ID of the
+// query's context. Queries are logged at debug level, slow queries at warn
+// and failures at error.
+//
+// It also counts the queries of contexts prepared with WithQueryStats and
+// warns once when a request exceeds the configured query budget, which
+// usually means an N+1 query.
+type queryLogger struct {
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+	redactParams  bool
+	queryBudget   int
+}
+
+func newQueryLogger(cfg config.DatabaseConfig) *queryLogger {
+	return &queryLogger{
+		level:         gormlogger.Info,
+		slowThreshold: cfg.SlowQueryThreshold,
+		redactParams:  cfg.RedactParams,
+		queryBudget:   cfg.QueryBudget,
+	}
+}
+
+func (l *queryLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *queryLogger) Info(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Info {
+		logger := l.logger(ctx)
+		logger.Info().Msg(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *queryLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Warn {
+		logger := l.logger(ctx)
+		logger.Warn().Msg(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *queryLogger) Error(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Error {
+		logger := l.logger(ctx)
+		logger.Error().Msg(fmt.Sprintf(msg, args...))
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter. With redaction enabled the
+// bound values are dropped, so logged SQL keeps its placeholders.
+func (l *queryLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if l.redactParams {
+		return sql, nil
+	}
+	return sql, params
+}
+
+func (l *queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	logger := l.logger(ctx)
+
+	stats := QueryStatsFromContext(ctx)
+	if stats != nil {
+		count := stats.add(elapsed)
+		if l.queryBudget > 0 && count == int64(l.queryBudget)+1 {
+			sql, _ := fc()
+			logger.Warn().
+				Int64("queries", count).
+				Int("budget", l.queryBudget).
+				Str("sql", sql).
+				Msg("Request exceeded its query budget, possible N+1 query")
+		}
+	}
+
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	var event *zerolog.Event
+	msg := "Query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		event = logger.Error().Err(err)
+		msg = "Query failed"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		event = logger.Warn().Dur("threshold", l.slowThreshold)
+		msg = "Slow query"
+	case l.level >= gormlogger.Info:
+		event = logger.Debug()
+	default:
+		return
+	}
+	if !event.Enabled() {
+		return
+	}
+
+	sql, rows := fc()
+	if stats != nil {
+		event = event.Int64("query_number", stats.Count())
+	}
+	event.
+		Str("sql", sql).
+		Int64("rows", rows).
+		Dur("elapsed", elapsed).
+		Msg(msg)
+}
+
+// logger returns the database logger, tagged with the request ID and the
+// trace of ctx.
+func (l *queryLogger) logger(ctx context.Context) zerolog.Logger {
+	logger := config.GetLogger("database")
+	if id := requestid.FromContext(ctx); id != "" {
+		logger = logger.With().Str("request_id", id).Logger()
+	}
+	return tracing.WithTraceIDs(ctx, logger)
+}
+
+// QueryStats counts the queries run on behalf of one request.
+type QueryStats struct {
+	count   atomic.Int64
+	elapsed atomic.Int64
+}
+
+// Count returns the number of queries run so far.
+func (s *QueryStats) Count() int64 {
+	return s.count.Load()
+}
+
+// Elapsed returns the total time spent in those queries.
+func (s *QueryStats) Elapsed() time.Duration {
+	return time.Duration(s.elapsed.Load())
+}
+
+func (s *QueryStats) add(elapsed time.Duration) int64 {
+	s.elapsed.Add(int64(elapsed))
+	return s.count.Add(1)
+}
+
+type queryStatsKey struct{}
+
+// WithQueryStats returns a copy of ctx in which queries are counted; see
+// QueryStatsFromContext.
+func WithQueryStats(ctx context.Context) context.Context {
+	return context.WithValue(ctx, queryStatsKey{}, &QueryStats{})
+}
+
+// QueryStatsFromContext returns the query counters of ctx, or nil if ctx
+// was not prepared with WithQueryStats.
+func QueryStatsFromContext(ctx context.Context) *QueryStats {
+	stats, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
+	return stats
+}
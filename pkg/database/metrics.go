@@ -0,0 +1,58 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gin-template/pkg/metrics"
+
+	"gorm.io/gorm"
+)
+
+// metricsStartKey is the statement setting holding a query's start time.
+const metricsStartKey = "metrics:start"
+
+// metricsPlugin observes the duration of every query in
+// metrics.DBQueryDuration, labelled by GORM operation.
+type metricsPlugin struct{}
+
+func (metricsPlugin) Name() string {
+	return "metrics"
+}
+
+func (metricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", startTimer),
+		cb.Create().After("gorm:create").Register("metrics:after_create", observe("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", startTimer),
+		cb.Query().After("gorm:query").Register("metrics:after_query", observe("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", startTimer),
+		cb.Update().After("gorm:update").Register("metrics:after_update", observe("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", startTimer),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", observe("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", startTimer),
+		cb.Row().After("gorm:row").Register("metrics:after_row", observe("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", startTimer),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", observe("raw")),
+	)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		start, ok := db.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		err := db.Error
+		// A lookup finding nothing is not a failed query
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		metrics.ObserveQuery(operation, time.Since(start.(time.Time)), err)
+	}
+}
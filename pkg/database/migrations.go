@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// sqlMigrations holds the migrations written as SQL files, named
+// "<version>_<name>.<up|down>[.<driver>].sql".
+//
+//go:embed migrations/*.sql
+var sqlMigrations embed.FS
+
+// goMigrations lists the migrations written in Go. Their versions share a
+// sequence with the SQL files.
+var goMigrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create_users",
+		// AutoMigrate on a snapshot of the model keeps the column types of
+		// each driver, and adopts tables created before migrations were
+		// versioned.
+		Up: migrate.Func(func(tx *gorm.DB) error {
+			return tx.Migrator().AutoMigrate(&userV1{})
+		}),
+		Down: migrate.Func(func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&userV1{})
+		}),
+	},
+}
+
+// userV1 is the users table as first created.
+type userV1 struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Name  string `gorm:"not null"`
+	Email string `gorm:"uniqueIndex;not null"`
+	Age   int
+	Phone string
+}
+
+func (userV1) TableName() string {
+	return "users"
+}
+
+// Migrations returns every migration for driver.
+func Migrations(driver string) ([]migrate.Migration, error) {
+	migrations, err := migrate.Load(sqlMigrations, "migrations", driver)
+	if err != nil {
+		return nil, err
+	}
+	return append(migrations, goMigrations...), nil
+}
+
+// NewMigrator returns a Migrator for the application's migrations.
+func NewMigrator(db *gorm.DB, cfg config.DatabaseConfig) (*migrate.Migrator, error) {
+	migrations, err := Migrations(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, migrations, cfg.MigrateLockTimeout)
+}
+
+// Migrate brings the schema up to date as cfg.Migrate says: applying the
+// pending migrations, running AutoMigrate on the models, or nothing.
+func Migrate(ctx context.Context, db *gorm.DB, cfg config.DatabaseConfig) error {
+	switch cfg.Migrate {
+	case "none":
+		return nil
+	case "auto":
+		return db.WithContext(ctx).AutoMigrate(schema()...)
+	default:
+		migrator, err := NewMigrator(db, cfg)
+		if err != nil {
+			return err
+		}
+		_, err = migrator.Up(ctx, 0)
+		return err
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"gin-template/pkg/config"
+
+	"gorm.io/gorm"
+)
+
+// Connect opens the database like Open, but while it is not reachable
+// retries with exponential backoff until cfg.Connect.Timeout passes or ctx
+// is done.
+func Connect(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dsn, err := DSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := config.GetLogger("database")
+	retry := cfg.Connect
+	start := time.Now()
+	deadline := start.Add(retry.Timeout)
+	backoff := retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if retry.Timeout > 0 {
+			attemptCtx, cancel = context.WithDeadline(ctx, deadline)
+		}
+		db, err := open(attemptCtx, cfg, dsn)
+		cancel()
+		if err == nil {
+			if attempt > 1 {
+				logger.Info().Int("attempts", attempt).Dur("took", time.Since(start)).Msg("Database reachable")
+			}
+			return db, nil
+		}
+		if retry.Timeout == 0 {
+			return nil, err
+		}
+
+		// Equal jitter spreads out instances restarting together
+		wait := backoff/2 + rand.N(backoff/2+1)
+		if time.Now().Add(wait).After(deadline) {
+			return nil, fmt.Errorf("database not reachable after %d attempts in %s: %w",
+				attempt, time.Since(start).Round(time.Millisecond), err)
+		}
+		logger.Warn().Err(err).Int("attempt", attempt).Dur("retry_in", wait).Msg("Database not reachable, retrying")
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, retry.MaxBackoff)
+	}
+}
+
+// configurePool applies cfg.Pool to db, leaving database/sql's default for
+// each zero setting.
+func configurePool(db *sql.DB, cfg config.DatabaseConfig) {
+	pool := cfg.Pool
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	// An in-memory SQLite database lives only as long as its connection
+	if cfg.Driver == "sqlite" && sqliteFile(cfg) == "" {
+		return
+	}
+	if pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+}
+
+// PoolStats describes a connection pool.
+type PoolStats struct {
+	Name string `json:"name"`
+	// Healthy is false for a replica failing its health check.
+	Healthy            bool    `json:"healthy"`
+	MaxOpenConnections int     `json:"max_open_connections"`
+	OpenConnections    int     `json:"open_connections"`
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"wait_count"`
+	WaitDurationMS     float64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64   `json:"max_lifetime_closed"`
+}
+
+func poolStats(name string, s sql.DBStats) PoolStats {
+	return PoolStats{
+		Name:               name,
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDurationMS:     float64(s.WaitDuration.Microseconds()) / 1000,
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/metrics"
+
+	"gorm.io/gorm"
+)
+
+// Cluster routes queries between the primary database and its read
+// replicas: writes, and the reads of a client that wrote within the sticky
+// window, go to the primary; other reads go to a healthy replica, or to
+// the primary when none is healthy.
+type Cluster struct {
+	primary  *gorm.DB
+	replicas []*replica
+	cfg      config.ReplicasConfig
+	next     atomic.Uint64
+
+	mu        sync.Mutex
+	writes    map[string]time.Time
+	lastSweep time.Time
+}
+
+// replica is a read replica, connected lazily by the health monitor when
+// it was unreachable at startup.
+type replica struct {
+	name    string
+	cfg     config.DatabaseConfig
+	db      atomic.Pointer[gorm.DB]
+	healthy atomic.Bool
+	checked atomic.Bool
+}
+
+// NewCluster returns a Cluster without replicas, sending every query to
+// primary.
+func NewCluster(primary *gorm.DB) *Cluster {
+	return &Cluster{primary: primary, writes: make(map[string]time.Time)}
+}
+
+// OpenCluster returns a Cluster of primary and the replicas in
+// cfg.Replicas, connecting to each once. Unreachable replicas do not fail
+// startup: they are skipped until Monitor connects to them.
+func OpenCluster(ctx context.Context, primary *gorm.DB, cfg config.DatabaseConfig) *Cluster {
+	c := NewCluster(primary)
+	c.cfg = cfg.Replicas
+	for i, dsn := range cfg.Replicas.DSNs {
+		replicaCfg := cfg
+		replicaCfg.DSN = dsn
+		replicaCfg.Replicas = config.ReplicasConfig{}
+		c.replicas = append(c.replicas, &replica{name: fmt.Sprintf("replica-%d", i+1), cfg: replicaCfg})
+	}
+	c.check(ctx)
+	return c
+}
+
+// Primary returns the primary database.
+func (c *Cluster) Primary() *gorm.DB {
+	return c.primary
+}
+
+// Write returns the primary database for the queries of ctx, and starts
+// the sticky window of the client of ctx, if any; see WithClient.
+func (c *Cluster) Write(ctx context.Context) *gorm.DB {
+	if client, ok := ctx.Value(clientKey{}).(string); ok && len(c.replicas) > 0 && c.cfg.StickyWindow > 0 {
+		now := time.Now()
+		c.mu.Lock()
+		if now.Sub(c.lastSweep) > c.cfg.StickyWindow {
+			for key, at := range c.writes {
+				if now.Sub(at) > c.cfg.StickyWindow {
+					delete(c.writes, key)
+				}
+			}
+			c.lastSweep = now
+		}
+		c.writes[client] = now
+		c.mu.Unlock()
+	}
+	return c.primary.WithContext(ctx)
+}
+
+// Read returns the database for the reads of ctx.
+func (c *Cluster) Read(ctx context.Context) *gorm.DB {
+	if len(c.replicas) == 0 {
+		return c.primary.WithContext(ctx)
+	}
+
+	if client, ok := ctx.Value(clientKey{}).(string); ok {
+		c.mu.Lock()
+		at, wrote := c.writes[client]
+		c.mu.Unlock()
+		if wrote && time.Since(at) <= c.cfg.StickyWindow {
+			metrics.DBReads.WithLabelValues("primary", "sticky").Inc()
+			return c.primary.WithContext(ctx)
+		}
+	}
+
+	if r := c.pick(); r != nil {
+		metrics.DBReads.WithLabelValues(r.name, "replica").Inc()
+		return r.db.Load().WithContext(ctx)
+	}
+	metrics.DBReads.WithLabelValues("primary", "fallback").Inc()
+	return c.primary.WithContext(ctx)
+}
+
+// pick chooses a healthy replica by the configured policy, or returns nil.
+func (c *Cluster) pick() *replica {
+	healthy := make([]*replica, 0, len(c.replicas))
+	for _, r := range c.replicas {
+		if r.healthy.Load() {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	switch c.cfg.Policy {
+	case "random":
+		return healthy[rand.N(len(healthy))]
+	case "least_connections":
+		best, fewest := healthy[0], -1
+		for _, r := range healthy {
+			sqlDB, err := r.db.Load().DB()
+			if err != nil {
+				continue
+			}
+			if inUse := sqlDB.Stats().InUse; fewest < 0 || inUse < fewest {
+				best, fewest = r, inUse
+			}
+		}
+		return best
+	default:
+		return healthy[(c.next.Add(1)-1)%uint64(len(healthy))]
+	}
+}
+
+// Monitor checks the replicas every cfg.Replicas.HealthInterval until ctx
+// is done.
+func (c *Cluster) Monitor(ctx context.Context) {
+	if len(c.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(c.cfg.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.check(ctx)
+		}
+	}
+}
+
+// check connects to or pings every replica and records whether it is
+// healthy.
+func (c *Cluster) check(ctx context.Context) {
+	logger := config.GetLogger("database")
+	for _, r := range c.replicas {
+		err := r.ping(ctx, c.cfg.HealthInterval)
+		healthy := err == nil
+		changed := r.healthy.Swap(healthy) != healthy
+		if first := !r.checked.Swap(true); first || changed {
+			if healthy {
+				logger.Info().Str("replica", r.name).Msg("Replica healthy, serving reads")
+			} else {
+				logger.Warn().Err(err).Str("replica", r.name).Msg("Replica unhealthy, skipping it for reads")
+			}
+		}
+		gauge := 0.0
+		if healthy {
+			gauge = 1
+		}
+		metrics.DBReplicaHealthy.WithLabelValues(r.name).Set(gauge)
+	}
+}
+
+// ping connects to the replica if needed and pings it within timeout.
+func (r *replica) ping(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	db := r.db.Load()
+	if db == nil {
+		dsn, err := DSN(r.cfg)
+		if err != nil {
+			return err
+		}
+		if db, err = open(ctx, r.cfg, dsn); err != nil {
+			return err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		metrics.RegisterDBStats(r.name, sqlDB)
+		r.db.Store(db)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes the connections to the primary and the replicas.
+func (c *Cluster) Close() error {
+	sqlDB, err := c.primary.DB()
+	if err != nil {
+		return err
+	}
+	errs := []error{sqlDB.Close()}
+	for _, r := range c.replicas {
+		if db := r.db.Load(); db != nil {
+			if sqlDB, err := db.DB(); err == nil {
+				errs = append(errs, sqlDB.Close())
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stats returns the statistics of the connection pools of the primary,
+// named "main", and of the replicas; those not connected yet report
+// zeros.
+func (c *Cluster) Stats() ([]PoolStats, error) {
+	sqlDB, err := c.primary.DB()
+	if err != nil {
+		return nil, err
+	}
+	stats := []PoolStats{poolStats("main", sqlDB.Stats())}
+	stats[0].Healthy = true
+	for _, r := range c.replicas {
+		db := r.db.Load()
+		if db == nil {
+			stats = append(stats, PoolStats{Name: r.name})
+			continue
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		s := poolStats(r.name, sqlDB.Stats())
+		s.Healthy = r.healthy.Load()
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+type clientKey struct{}
+
+// WithClient returns a copy of ctx whose queries are made on behalf of
+// client, e.g. its IP address, so that after a write the client's reads go
+// to the primary for the sticky window.
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"gin-template/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracingSpanKey is the statement setting holding a query's span.
+const tracingSpanKey = "tracing:span"
+
+// querySpan is a query's span and the statement context it replaced.
+type querySpan struct {
+	span   trace.Span
+	parent context.Context
+}
+
+// tracingPlugin traces every query in a client span below the span of the
+// statement's context, named after the GORM operation and the table.
+type tracingPlugin struct{}
+
+func (tracingPlugin) Name() string {
+	return "tracing"
+}
+
+func (tracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", startSpan("create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", endSpan("create")),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", startSpan("query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", endSpan("query")),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", startSpan("update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", endSpan("update")),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", startSpan("delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", endSpan("delete")),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", startSpan("row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", endSpan("row")),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", startSpan("raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", endSpan("raw")),
+	)
+}
+
+func startSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		parent := db.Statement.Context
+		ctx, span := tracing.Start(parent, operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(db.Dialector.Name()),
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(tracingSpanKey, querySpan{span: span, parent: parent})
+	}
+}
+
+func endSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(tracingSpanKey)
+		if !ok {
+			return
+		}
+		qs := value.(querySpan)
+		defer qs.span.End()
+
+		// Statements can be reused by chained calls (e.g. Count then
+		// Find), whose spans must not nest.
+		db.Statement.Context = qs.parent
+
+		if table := db.Statement.Table; table != "" {
+			qs.span.SetName(operation + " " + table)
+			qs.span.SetAttributes(semconv.DBCollectionName(table))
+		}
+		qs.span.SetAttributes(
+			semconv.DBQueryText(db.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", db.RowsAffected),
+		)
+		// A lookup finding nothing is not a failed query
+		if !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			tracing.Fail(qs.span, db.Error)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Pinger is implemented by *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Ping checks that a database answers within the check timeout.
+func Ping(db Pinger) Checker {
+	return CheckerFunc(db.PingContext)
+}
+
+// errDiskSpaceUnsupported is returned by freeSpace on platforms where it
+// is not implemented; DiskSpace then always passes.
+var errDiskSpaceUnsupported = errors.New("disk space check not supported")
+
+// DiskSpace checks that the file system holding path has at least
+// minFree bytes available, e.g. for a SQLite database file.
+func DiskSpace(path string, minFree uint64) Checker {
+	return CheckerFunc(func(context.Context) error {
+		free, err := freeSpace(path)
+		if errors.Is(err, errDiskSpaceUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%d MB free, want at least %d MB", free>>20, minFree>>20)
+		}
+		return nil
+	})
+}
+
+// Worker reports the health of a background goroutine. It is healthy
+// until Stop is called with an error and, when it expects heartbeats,
+// only while Beat has been called recently:
+//
+//	w := health.NewWorker(time.Minute)
+//	checks.Register("mailer", w, health.AffectsLiveness())
+//	go func() { w.Stop(runMailer(ctx, w)) }()
+type Worker struct {
+	maxSilence time.Duration
+
+	mu       sync.Mutex
+	lastBeat time.Time
+	err      error
+}
+
+// NewWorker returns a healthy Worker. With maxSilence > 0 the worker turns
+// unhealthy when Beat has not been called for that long.
+func NewWorker(maxSilence time.Duration) *Worker {
+	return &Worker{maxSilence: maxSilence, lastBeat: time.Now()}
+}
+
+// Beat records that the worker is making progress.
+func (w *Worker) Beat() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastBeat = time.Now()
+}
+
+// Stop records that the worker exited; a nil err means it finished as
+// expected, e.g. on shutdown, and leaves it healthy.
+func (w *Worker) Stop(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.err = fmt.Errorf("stopped: %w", err)
+	}
+}
+
+// Check implements Checker.
+func (w *Worker) Check(context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	if silence := time.Since(w.lastBeat); w.maxSilence > 0 && silence > w.maxSilence {
+		return fmt.Errorf("no heartbeat for %s", silence.Round(time.Second))
+	}
+	return nil
+}
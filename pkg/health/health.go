@@ -0,0 +1,199 @@
+// Package health runs the named checks behind the liveness and readiness
+// endpoints. Subsystems register a Checker with a Registry; probes run
+// every check of a kind concurrently and report each result with its
+// latency. Results are cached briefly so frequent probes from several load
+// balancers do not hammer the database.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Checker reports whether a subsystem is healthy. Check must return
+// promptly once ctx is done.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker.
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Kind selects the checks run by a probe.
+type Kind int
+
+const (
+	// Liveness checks fail when the process is broken in a way a restart
+	// fixes, such as a stopped background worker.
+	Liveness Kind = iota
+	// Readiness checks fail when the process should not receive traffic,
+	// such as when the database is unreachable. Every check, liveness
+	// included, is part of readiness.
+	Readiness
+)
+
+// Status is the outcome of a check or a probe.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS float64   `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the outcome of a probe: it passes when every check passes.
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Option customises a registered check.
+type Option func(*check)
+
+// WithTimeout bounds how long the check may run, instead of the registry
+// default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// AffectsLiveness makes the check part of liveness as well as readiness.
+// Use it only for failures that restarting the process fixes.
+func AffectsLiveness() Option {
+	return func(c *check) {
+		c.kind = Liveness
+	}
+}
+
+type check struct {
+	name    string
+	checker Checker
+	kind    Kind
+	timeout time.Duration
+
+	// mu serialises runs so concurrent probes share one result.
+	mu   sync.Mutex
+	last Result
+}
+
+// Registry holds the registered checks.
+type Registry struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.RWMutex
+	checks []*check
+}
+
+// NewRegistry returns an empty Registry. Checks time out after timeout
+// unless registered with WithTimeout, and their results are reused for
+// cacheTTL; 0 runs them on every probe.
+func NewRegistry(timeout, cacheTTL time.Duration) *Registry {
+	return &Registry{timeout: timeout, cacheTTL: cacheTTL}
+}
+
+// Register adds a readiness check named name. Registering a name again
+// replaces the previous check.
+func (r *Registry) Register(name string, checker Checker, opts ...Option) {
+	c := &check{name: name, checker: checker, kind: Readiness, timeout: r.timeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.checks {
+		if existing.name == name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// Run runs the checks of kind concurrently, reusing results younger than
+// the cache TTL, and reports them in registration order.
+func (r *Registry) Run(ctx context.Context, kind Kind) Report {
+	r.mu.RLock()
+	var checks []*check
+	for _, c := range r.checks {
+		if kind == Readiness || c.kind == Liveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusPass, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, r.cacheTTL)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusPass {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (c *check) run(ctx context.Context, cacheTTL time.Duration) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.last.CheckedAt.IsZero() && time.Since(c.last.CheckedAt) < cacheTTL {
+		return c.last
+	}
+
+	// The result is shared with other probes, so a probe giving up must
+	// not fail the check; the timeout still bounds it.
+	ctx = context.WithoutCancel(ctx)
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := c.checker.Check(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = errors.New("timed out")
+	}
+
+	result := Result{
+		Name:      c.name,
+		Status:    StatusPass,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	c.last = result
+	return result
+}
@@ -0,0 +1,87 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gin-template/pkg/config"
+)
+
+// Hook is a named function run during shutdown.
+type Hook struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Lifecycle tracks whether the process is shutting down and runs the
+// registered shutdown hooks.
+type Lifecycle struct {
+	mu           sync.Mutex
+	hooks        []Hook
+	shuttingDown atomic.Bool
+}
+
+// New returns a Lifecycle with no hooks.
+func New() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// OnShutdown registers fn to run on shutdown. Hooks run in reverse order of
+// registration, so resources are released after everything that uses them.
+func (l *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, Hook{Name: name, Fn: fn})
+}
+
+// Ready reports whether the process should receive traffic. It turns false
+// as soon as shutdown begins.
+func (l *Lifecycle) Ready() bool {
+	return !l.shuttingDown.Load()
+}
+
+// BeginShutdown marks the process as shutting down, failing readiness.
+func (l *Lifecycle) BeginShutdown() {
+	l.shuttingDown.Store(true)
+}
+
+// HookError reports the shutdown hooks that failed.
+type HookError struct {
+	Errs []error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%d shutdown hook(s) failed: %v", len(e.Errs), errors.Join(e.Errs...))
+}
+
+// RunHooks runs every registered hook in reverse order, even if earlier
+// ones fail, and returns a *HookError listing the failures.
+func (l *Lifecycle) RunHooks(ctx context.Context) error {
+	logger := config.GetLogger("lifecycle")
+
+	l.mu.Lock()
+	hooks := make([]Hook, len(l.hooks))
+	copy(hooks, l.hooks)
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		start := time.Now()
+		if err := hook.Fn(ctx); err != nil {
+			logger.Error().Err(err).Str("hook", hook.Name).Msg("Shutdown hook failed")
+			errs = append(errs, fmt.Errorf("%s: %w", hook.Name, err))
+			continue
+		}
+		logger.Debug().Str("hook", hook.Name).Dur("took", time.Since(start)).Msg("Shutdown hook completed")
+	}
+
+	if len(errs) > 0 {
+		return &HookError{Errs: errs}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Package metrics defines the Prometheus metrics of the service and the
+// registry they are served from.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric of the service, including Go runtime and
+// process metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts handled requests.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by route template, method and status.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPDuration observes request latency.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by route template, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// HTTPInFlight is the number of requests being served.
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests currently being served.",
+	})
+
+	// BindingFailures counts requests rejected by parameter binding.
+	BindingFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_binding_failures_total",
+		Help: "Requests rejected while binding parameters, by route template, method and kind (validation or decode).",
+	}, []string{"route", "method", "kind"})
+
+	// DBQueryDuration observes database query latency.
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Database query latency, by operation (create, query, update, delete, row, raw) and outcome.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "outcome"})
+
+	// DBReads counts the reads routed between the primary and replicas.
+	DBReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_reads_total",
+		Help: "Reads routed to each database (primary or a replica name), by reason (replica, sticky, fallback).",
+	}, []string{"target", "reason"})
+
+	// DBReplicaHealthy reports whether each read replica passes its
+	// health check.
+	DBReplicaHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "db_replica_healthy",
+		Help: "1 while the read replica passes its health check, else 0.",
+	}, []string{"replica"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		HTTPInFlight,
+		BindingFailures,
+		DBQueryDuration,
+		DBReads,
+		DBReplicaHealthy,
+	)
+}
+
+// Handler serves the metrics in Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveQuery records one database query.
+func ObserveQuery(operation string, elapsed time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	DBQueryDuration.WithLabelValues(operation, outcome).Observe(elapsed.Seconds())
+}
+
+var dbStats struct {
+	sync.Mutex
+	collectors map[string]prometheus.Collector
+}
+
+// RegisterDBStats exports the connection pool statistics of db (open, idle
+// and in-use connections, waits, closes) with the db_name label name. It
+// replaces the pool registered before under that name, if any.
+func RegisterDBStats(name string, db *sql.DB) {
+	dbStats.Lock()
+	defer dbStats.Unlock()
+
+	if collector, ok := dbStats.collectors[name]; ok {
+		Registry.Unregister(collector)
+	}
+	if dbStats.collectors == nil {
+		dbStats.collectors = make(map[string]prometheus.Collector)
+	}
+	dbStats.collectors[name] = collectors.NewDBStatsCollector(db, name)
+	Registry.MustRegister(dbStats.collectors[name])
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"gin-template/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth 管理接口认证中间件
+//
+// It requires "Authorization: Bearer <token>" matching the admin token in
+// cfg. Without a configured token every request is refused, so privileged
+// endpoints are never left open by accident.
+func AdminAuth(cfg *config.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := cfg.Get().Server.Admin.Token.Value()
+		if token == "" {
+			ErrorResponse(c, http.StatusForbidden, "admin token is not configured")
+			c.Abort()
+			return
+		}
+
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			ErrorResponse(c, http.StatusUnauthorized, "invalid or missing admin token")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
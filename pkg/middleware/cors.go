@@ -1,13 +1,29 @@
 package middleware
 
 import (
+	"slices"
+
+	"gin-template/pkg/config"
+
 	"github.com/gin-gonic/gin"
 )
 
 // CORS 跨域中间件
-func CORS() gin.HandlerFunc {
+//
+// Allowed origins are read from cfg on every request so a configuration
+// reload takes effect immediately.
+func CORS(cfg *config.Provider) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
+		origins := cfg.Get().Server.CORS.AllowedOrigins
+		origin := c.GetHeader("Origin")
+
+		switch {
+		case slices.Contains(origins, "*"):
+			c.Header("Access-Control-Allow-Origin", "*")
+		case origin != "" && slices.Contains(origins, origin):
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Vary", "Origin")
+		}
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
 		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
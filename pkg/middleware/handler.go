@@ -4,9 +4,14 @@ import (
 	"net/http"
 	"reflect"
 
+	"gin-template/pkg/config"
+	"gin-template/pkg/metrics"
+	"gin-template/pkg/requestid"
+	"gin-template/pkg/tracing"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Response represents the unified response structure
@@ -14,13 +19,16 @@ type Response struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	Data    any    `json:"data,omitempty"`
+	// RequestID is set on errors so clients can quote it when reporting them.
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // ErrorResponse sends an error response
 func ErrorResponse(c *gin.Context, code int, message string) {
 	c.JSON(code, Response{
-		Code:    code,
-		Message: message,
+		Code:      code,
+		Message:   message,
+		RequestID: requestid.FromContext(c.Request.Context()),
 	})
 }
 
@@ -39,11 +47,12 @@ type Handler any
 // BindAndCall creates a middleware that automatically binds parameters and calls handler
 func BindAndCall(handler Handler, bindTypes ...any) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		logger := log.With().
+		logger := config.GetLogger("middleware").With().
+			Str("request_id", requestid.FromContext(c.Request.Context())).
 			Str("method", c.Request.Method).
 			Str("path", c.Request.URL.Path).
-			Str("component", "middleware").
 			Logger()
+		logger = tracing.WithTraceIDs(c.Request.Context(), logger)
 
 		// Prepare arguments
 		args := []reflect.Value{reflect.ValueOf(c)}
@@ -56,29 +65,37 @@ func BindAndCall(handler Handler, bindTypes ...any) gin.HandlerFunc {
 			param := paramValue.Interface()
 
 			var err error
+			_, span := tracing.Start(c.Request.Context(), "bind "+paramType.Name())
 
 			// Decide binding method based on HTTP method
 			switch c.Request.Method {
 			case "GET", "DELETE":
 				err = c.ShouldBindQuery(param)
 				logger.Debug().Str("binding_type", "query").Msg("Binding query parameters")
+				span.SetAttributes(attribute.String("binding.type", "query"))
 			case "POST", "PUT", "PATCH":
 				if i == 0 {
 					// First parameter is usually JSON body
 					err = c.ShouldBindJSON(param)
 					logger.Debug().Str("binding_type", "json").Msg("Binding JSON body")
+					span.SetAttributes(attribute.String("binding.type", "json"))
 				} else {
 					// Other parameters might be query parameters
 					err = c.ShouldBindQuery(param)
 					logger.Debug().Str("binding_type", "query").Msg("Binding query parameters")
+					span.SetAttributes(attribute.String("binding.type", "query"))
 				}
 			}
+			tracing.Fail(span, err)
+			span.End()
 
 			if err != nil {
 				logger.Error().Err(err).Msg("Parameter binding failed")
 				if validationErr, ok := err.(validator.ValidationErrors); ok {
+					metrics.BindingFailures.WithLabelValues(routeLabel(c), c.Request.Method, "validation").Inc()
 					ErrorResponse(c, http.StatusBadRequest, validationErr.Error())
 				} else {
+					metrics.BindingFailures.WithLabelValues(routeLabel(c), c.Request.Method, "decode").Inc()
 					ErrorResponse(c, http.StatusBadRequest, err.Error())
 				}
 				c.Abort()
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/database"
+	"gin-template/pkg/requestid"
+	"gin-template/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// UserIDKey is the gin context key under which authentication middleware
+// stores the ID of the authenticated user; the access log reports it.
+const UserIDKey = "user_id"
+
+// AccessLog 访问日志中间件
+//
+// It logs one structured line per request once the response is written:
+// method, route template, status, latency, response size, client IP, user
+// agent, request ID, the authenticated user ID and the number of database
+// queries with the time spent in them. Requests to the skip paths in cfg
+// are not logged.
+//
+// AccessLog must run after RequestID and Tracing. The request logger,
+// carrying the request and trace IDs, is stored in the request context so
+// controllers and services can log with the same fields through
+// zerolog.Ctx(ctx).
+func AccessLog(cfg *config.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		logger := config.GetLogger("http")
+		if id := requestid.FromContext(c.Request.Context()); id != "" {
+			logger = logger.With().Str("request_id", id).Logger()
+		}
+		logger = tracing.WithTraceIDs(c.Request.Context(), logger)
+		ctx := database.WithQueryStats(logger.WithContext(c.Request.Context()))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		access := cfg.Get().Log.Access
+		if !access.Enabled || slices.Contains(access.SkipPaths, c.Request.URL.Path) {
+			return
+		}
+
+		status := c.Writer.Status()
+		event := logger.Info()
+		switch {
+		case status >= 500:
+			event = logger.Error()
+		case status >= 400:
+			event = logger.Warn()
+		}
+
+		event = event.
+			Str("method", c.Request.Method).
+			Str("route", routeLabel(c)).
+			Str("path", c.Request.URL.Path).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Int("bytes", max(c.Writer.Size(), 0)).
+			Str("client_ip", c.ClientIP()).
+			Str("user_agent", c.Request.UserAgent())
+		if stats := database.QueryStatsFromContext(ctx); stats.Count() > 0 {
+			event = event.
+				Int64("queries", stats.Count()).
+				Dur("query_time", stats.Elapsed())
+		}
+		if userID, ok := c.Get(UserIDKey); ok {
+			event = event.Str("user_id", fmt.Sprint(userID))
+		}
+		if len(c.Errors) > 0 {
+			event = event.Str("errors", c.Errors.String())
+		}
+		event.Msg("Request handled")
+	}
+}
+
+// Logger returns the request logger installed by AccessLog, or the global
+// logger when the request did not go through it.
+func Logger(c *gin.Context) *zerolog.Logger {
+	return zerolog.Ctx(c.Request.Context())
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"gin-template/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 请求指标中间件
+//
+// It counts requests and observes their latency by route template, method
+// and status, and tracks requests in flight.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		c.Next()
+
+		labels := []string{routeLabel(c), c.Request.Method, strconv.Itoa(c.Writer.Status())}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	}
+}
+
+// routeLabel returns the route template of the request, so metrics and
+// logs are not split by path parameters, or "unmatched" for 404s.
+func routeLabel(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return "unmatched"
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gin-template/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// limiterIdleTTL is how long a client's bucket is kept after its last request.
+const limiterIdleTTL = 10 * time.Minute
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimit 限流中间件
+//
+// Each client IP gets its own token bucket. Limits are read from cfg on
+// every request, so a configuration reload resizes existing buckets in
+// place.
+func RateLimit(cfg *config.Provider) gin.HandlerFunc {
+	var (
+		mu        sync.Mutex
+		clients   = make(map[string]*clientLimiter)
+		lastSweep = time.Now()
+	)
+
+	return func(c *gin.Context) {
+		settings := cfg.Get().Server.RateLimit
+		if !settings.Enabled {
+			c.Next()
+			return
+		}
+
+		limit := rate.Limit(settings.RequestsPerSecond)
+		now := time.Now()
+
+		mu.Lock()
+		if now.Sub(lastSweep) > limiterIdleTTL {
+			for ip, cl := range clients {
+				if now.Sub(cl.lastSeen) > limiterIdleTTL {
+					delete(clients, ip)
+				}
+			}
+			lastSweep = now
+		}
+
+		cl, ok := clients[c.ClientIP()]
+		if !ok {
+			cl = &clientLimiter{limiter: rate.NewLimiter(limit, settings.Burst)}
+			clients[c.ClientIP()] = cl
+		}
+		cl.lastSeen = now
+		if cl.limiter.Limit() != limit {
+			cl.limiter.SetLimitAt(now, limit)
+		}
+		if cl.limiter.Burst() != settings.Burst {
+			cl.limiter.SetBurstAt(now, settings.Burst)
+		}
+		reservation := cl.limiter.ReserveN(now, 1)
+		mu.Unlock()
+
+		if delay := reservation.DelayFrom(now); !reservation.OK() || delay > 0 {
+			reservation.CancelAt(now)
+			retryAfter := 1
+			if reservation.OK() {
+				retryAfter = int(math.Ceil(delay.Seconds()))
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			ErrorResponse(c, http.StatusTooManyRequests, "Too many requests")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"gin-template/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadYourWrites 读写一致性中间件
+//
+// It tags the request context with the client IP (see
+// database.WithClient), so that after a write the client's reads go to
+// the primary for the sticky window instead of a possibly lagging
+// replica.
+func ReadYourWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.WithClient(c.Request.Context(), c.ClientIP()))
+		c.Next()
+	}
+}
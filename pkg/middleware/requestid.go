@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"gin-template/pkg/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDKey is the gin context key holding the request ID.
+const RequestIDKey = "request_id"
+
+// RequestID 请求 ID 中间件
+//
+// It reuses a valid X-Request-ID sent by the client or a proxy, or
+// generates one, echoes it in the response header and stores it in the
+// request context (see requestid.FromContext) so logs, database queries
+// and outbound calls made for the request carry it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
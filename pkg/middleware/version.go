@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"gin-template/pkg/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionHeader is the response header carrying the build version.
+const VersionHeader = "X-App-Version"
+
+// Version 版本响应头中间件
+//
+// Every response carries the version of the build that served it.
+func Version() gin.HandlerFunc {
+	v := version.Get().Version
+	return func(c *gin.Context) {
+		c.Header(VersionHeader, v)
+		c.Next()
+	}
+}
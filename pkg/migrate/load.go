@@ -0,0 +1,72 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+)
+
+// fileName matches "<version>_<name>.<up|down>[.<driver>].sql".
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)(?:\.(\w+))?\.sql$`)
+
+// Load reads SQL migrations from dir in fsys. Each file is named
+// "<version>_<name>.<up|down>.sql"; a file with a driver before the
+// extension, such as "0002_add_index.down.mysql.sql", replaces the generic
+// one for that driver and is ignored for others. Files not matching the
+// pattern are skipped.
+func Load(fsys fs.FS, dir string, driver string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	// Whether a step came from a driver-specific file
+	specific := map[string]bool{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		fileDriver := match[4]
+		if fileDriver != "" && fileDriver != driver {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %s: version %d is also named %q", entry.Name(), version, migration.Name)
+		}
+
+		key := match[1] + "." + match[3]
+		if specific[key] && fileDriver == "" {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			migration.Up = SQL(string(content))
+		} else {
+			migration.Down = SQL(string(content))
+		}
+		specific[key] = fileDriver != ""
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	return migrations, nil
+}
@@ -0,0 +1,189 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gin-template/pkg/config"
+
+	"gorm.io/gorm/clause"
+)
+
+// lockName names the MySQL advisory lock.
+const lockName = "schema_migrations"
+
+// postgresLockKey identifies the PostgreSQL advisory lock.
+const postgresLockKey int64 = 0x6d6967726174 // "migrat"
+
+// lockPollInterval is how often a held table lock is retried.
+const lockPollInterval = 250 * time.Millisecond
+
+// schemaMigrationLock is the single row of the schema_migrations_lock
+// table, present while an instance migrates a database without advisory
+// locks.
+type schemaMigrationLock struct {
+	ID       int    `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string `gorm:"size:255;not null"`
+	LockedAt time.Time
+}
+
+func (schemaMigrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// advisory reports whether the database has session-level advisory locks,
+// which are released even if the process holding them dies.
+func (m *Migrator) advisory() bool {
+	name := m.db.Dialector.Name()
+	return name == "mysql" || name == "postgres"
+}
+
+// withLock runs fn while holding the migration lock: an advisory lock on
+// MySQL and PostgreSQL, or else a row in the schema_migrations_lock table.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	var unlock func() error
+	var err error
+	if m.advisory() {
+		unlock, err = m.advisoryLock(ctx)
+	} else {
+		unlock, err = m.tableLock(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(fn(), unlock())
+}
+
+// advisoryLock takes an advisory lock on a dedicated connection, which
+// holds it until released or closed.
+func (m *Migrator) advisoryLock(ctx context.Context) (unlock func() error, err error) {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	release := "SELECT RELEASE_LOCK(?)"
+	var key any = lockName
+	var acquired bool
+	if m.db.Dialector.Name() == "postgres" {
+		release, key = "SELECT pg_advisory_unlock($1)", postgresLockKey
+		acquired, err = m.pollPostgresLock(ctx, conn)
+	} else {
+		var result sql.NullInt64
+		err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(m.lockTimeout.Seconds())).Scan(&result)
+		acquired = result.Int64 == 1
+	}
+	if err == nil && !acquired {
+		err = fmt.Errorf("timed out after %s waiting for the migration lock", m.lockTimeout)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return func() error {
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), release, key)
+		return errors.Join(err, conn.Close())
+	}, nil
+}
+
+// pollPostgresLock tries the PostgreSQL advisory lock until it is acquired
+// or the lock timeout passes.
+func (m *Migrator) pollPostgresLock(ctx context.Context, conn *sql.Conn) (bool, error) {
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", postgresLockKey).Scan(&acquired); err != nil {
+			return false, err
+		}
+		if acquired || time.Now().After(deadline) {
+			return acquired, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// tableLock inserts the lock row, waiting while another instance holds it.
+func (m *Migrator) tableLock(ctx context.Context) (unlock func() error, err error) {
+	db := m.db.WithContext(ctx)
+	if err := createTable(db, &schemaMigrationLock{}); err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s:%d", host, os.Getpid())
+	deadline := time.Now().Add(m.lockTimeout)
+	logged := false
+	for {
+		// Only read while the lock is held: on SQLite a concurrent write
+		// would make the holder's migrations fail with "database is locked"
+		var holders []schemaMigrationLock
+		if err := db.Limit(1).Find(&holders, 1).Error; err != nil {
+			return nil, err
+		}
+		if len(holders) == 0 {
+			result := db.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&schemaMigrationLock{ID: 1, Owner: owner, LockedAt: time.Now().UTC()})
+			if result.Error != nil {
+				return nil, result.Error
+			}
+			if result.RowsAffected == 1 {
+				break
+			}
+			continue
+		}
+
+		holder := holders[0]
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out after %s waiting for the migration lock held by %s since %s; "+
+				"if no migration is running, release it with `migrate unlock`",
+				m.lockTimeout, holder.Owner, holder.LockedAt.Format(time.RFC3339))
+		}
+		if !logged {
+			logger := config.GetLogger("migrate")
+			logger.Info().Str("owner", holder.Owner).Msg("Waiting for another instance to finish migrating")
+			logged = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	return func() error {
+		return m.db.WithContext(context.WithoutCancel(ctx)).
+			Where("owner = ?", owner).
+			Delete(&schemaMigrationLock{ID: 1}).Error
+	}, nil
+}
+
+// Unlock releases a table lock left behind by an instance that died while
+// migrating. Advisory locks need no unlocking: the database releases them
+// when the connection closes.
+func (m *Migrator) Unlock(ctx context.Context) (released bool, err error) {
+	if m.advisory() {
+		return false, nil
+	}
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&schemaMigrationLock{}) {
+		return false, nil
+	}
+	result := db.Delete(&schemaMigrationLock{ID: 1})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,335 @@
+// Package migrate applies ordered, versioned schema migrations, written as
+// SQL files or Go functions, and records them in the schema_migrations
+// table. A database-level lock ensures only one instance migrates at a
+// time when several start together.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gin-template/pkg/config"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one versioned schema change.
+type Migration struct {
+	// Version orders migrations; it is usually a sequence number or a
+	// timestamp such as 20240101120000.
+	Version int64
+	Name    string
+	// Up applies the migration and Down reverts it. A migration without
+	// Down cannot be rolled back.
+	Up   Step
+	Down Step
+}
+
+// String returns "<version> <name>".
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d %s", m.Version, m.Name)
+}
+
+// Step is the SQL or Go function of one direction of a migration.
+type Step struct {
+	// SQL holds statements separated by a semicolon at the end of a line.
+	SQL string
+	// Func runs in the migration's transaction when SQL is empty.
+	Func func(tx *gorm.DB) error
+}
+
+// SQL returns a Step running statements.
+func SQL(statements string) Step {
+	return Step{SQL: statements}
+}
+
+// Func returns a Step running fn.
+func Func(fn func(tx *gorm.DB) error) Step {
+	return Step{Func: fn}
+}
+
+// Empty reports whether the step does nothing.
+func (s Step) Empty() bool {
+	return s.SQL == "" && s.Func == nil
+}
+
+func (s Step) run(tx *gorm.DB) error {
+	if s.SQL == "" {
+		return s.Func(tx)
+	}
+	for _, statement := range splitStatements(s.SQL) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements splits SQL on semicolons ending a line, dropping empty
+// statements and comment-only lines.
+func splitStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// schemaMigration is a row of the schema_migrations table.
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applies a set of migrations to a database.
+type Migrator struct {
+	db          *gorm.DB
+	migrations  []Migration
+	lockTimeout time.Duration
+}
+
+// New returns a Migrator for migrations, which may be given in any order
+// but must have distinct versions. Waiting for another instance's lock
+// gives up after lockTimeout.
+func New(db *gorm.DB, migrations []Migration, lockTimeout time.Duration) (*Migrator, error) {
+	sorted := slices.Clone(migrations)
+	slices.SortFunc(sorted, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	for i, m := range sorted {
+		if m.Up.Empty() {
+			return nil, fmt.Errorf("migration %s has no up step", m)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("migrations %s and %s share a version", sorted[i-1], m)
+		}
+	}
+	return &Migrator{db: db, migrations: sorted, lockTimeout: lockTimeout}, nil
+}
+
+// Status describes a migration and whether it is applied.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Unknown marks a version recorded in the database that no migration
+	// defines, e.g. after deploying an older build.
+	Unknown bool
+}
+
+// Status lists every migration in order, followed by unknown versions
+// recorded in the database.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	var unknown []Status
+	for _, row := range applied {
+		unknown = append(unknown, Status{
+			Migration: Migration{Version: row.Version, Name: row.Name},
+			Applied:   true,
+			AppliedAt: row.AppliedAt,
+			Unknown:   true,
+		})
+	}
+	slices.SortFunc(unknown, func(a, b Status) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return append(statuses, unknown...), nil
+}
+
+// Pending returns the migrations Up would apply to reach version target;
+// 0 means the latest.
+func (m *Migrator) Pending(ctx context.Context, target int64) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.pending(applied, target), nil
+}
+
+func (m *Migrator) pending(applied map[int64]schemaMigration, target int64) []Migration {
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// Up applies the pending migrations up to version target, 0 meaning the
+// latest, each in its own transaction, and returns those applied. It
+// holds the migration lock while it runs.
+func (m *Migrator) Up(ctx context.Context, target int64) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		// Read after locking: another instance may just have migrated
+		if err := createTable(m.db.WithContext(ctx), &schemaMigration{}); err != nil {
+			return err
+		}
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.pending(applied, target) {
+			if err := m.apply(ctx, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Rollbacks returns the migrations Down would revert for steps.
+func (m *Migrator) Rollbacks(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.rollbacks(applied, steps), nil
+}
+
+func (m *Migrator) rollbacks(applied map[int64]schemaMigration, steps int) []Migration {
+	var rollbacks []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(rollbacks) < steps; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			rollbacks = append(rollbacks, m.migrations[i])
+		}
+	}
+	return rollbacks
+}
+
+// Down reverts the last steps applied migrations, newest first, and
+// returns those reverted. It stops at a migration without a down step.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		if err := createTable(m.db.WithContext(ctx), &schemaMigration{}); err != nil {
+			return err
+		}
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.rollbacks(applied, steps) {
+			if migration.Down.Empty() {
+				return fmt.Errorf("migration %s cannot be rolled back", migration)
+			}
+			if err := m.apply(ctx, migration, false); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// apply runs one direction of migration and records it, in a transaction.
+func (m *Migrator) apply(ctx context.Context, migration Migration, up bool) error {
+	logger := config.GetLogger("migrate")
+	start := time.Now()
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if !up {
+			if err := migration.Down.run(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+		}
+
+		if err := migration.Up.run(tx); err != nil {
+			return err
+		}
+		return tx.Create(&schemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now().UTC(),
+		}).Error
+	})
+	if err != nil {
+		direction := "up"
+		if !up {
+			direction = "down"
+		}
+		return fmt.Errorf("migration %s (%s): %w", migration, direction, err)
+	}
+
+	event := logger.Info().Int64("version", migration.Version).Str("name", migration.Name).Dur("took", time.Since(start))
+	if up {
+		event.Msg("Migration applied")
+	} else {
+		event.Msg("Migration rolled back")
+	}
+	return nil
+}
+
+// applied reads the schema_migrations table. Without the table nothing is
+// applied: it is only created by Up and Down, so that Status and the dry
+// runs never change the schema.
+func (m *Migrator) applied(ctx context.Context) (map[int64]schemaMigration, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return map[int64]schemaMigration{}, nil
+	}
+
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// createTable creates the table of model if missing. Instances starting
+// together may race to create it, so losing that race is not an error.
+func createTable(db *gorm.DB, model any) error {
+	if err := db.AutoMigrate(model); err != nil && !db.Migrator().HasTable(model) {
+		return err
+	}
+	return nil
+}
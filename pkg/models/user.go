@@ -6,35 +6,41 @@ import (
 	"gorm.io/gorm"
 )
 
+// Sensitive returns the models with fields tagged `log:"sensitive"`, for
+// the application to register with config.RegisterSensitive.
+func Sensitive() []any {
+	return []any{User{}, CreateUserRequest{}, UpdateUserRequest{}, GetUsersQuery{}}
+}
+
 type User struct {
 	ID        uint           `json:"id" gorm:"primarykey"`
-	CreatedAt time.Time      `json:"created_at"`
+	CreatedAt time.Time      `json:"created_at" gorm:"index"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
 	Name  string `json:"name" binding:"required" gorm:"not null"`
-	Email string `json:"email" binding:"required,email" gorm:"uniqueIndex;not null"`
+	Email string `json:"email" binding:"required,email" gorm:"uniqueIndex;not null" log:"sensitive"`
 	Age   int    `json:"age" binding:"min=1,max=150"`
-	Phone string `json:"phone"`
+	Phone string `json:"phone" log:"sensitive"`
 }
 
 type CreateUserRequest struct {
 	Name  string `json:"name" binding:"required"`
-	Email string `json:"email" binding:"required,email"`
+	Email string `json:"email" binding:"required,email" log:"sensitive"`
 	Age   int    `json:"age" binding:"min=1,max=150"`
-	Phone string `json:"phone"`
+	Phone string `json:"phone" log:"sensitive"`
 }
 
 type UpdateUserRequest struct {
 	Name  *string `json:"name"`
-	Email *string `json:"email" binding:"omitempty,email"`
+	Email *string `json:"email" binding:"omitempty,email" log:"sensitive"`
 	Age   *int    `json:"age" binding:"omitempty,min=1,max=150"`
-	Phone *string `json:"phone"`
+	Phone *string `json:"phone" log:"sensitive"`
 }
 
 type GetUsersQuery struct {
 	Page     int    `form:"page" binding:"omitempty,min=1"`
 	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
 	Name     string `form:"name"`
-	Email    string `form:"email"`
+	Email    string `form:"email" log:"sensitive"`
 }
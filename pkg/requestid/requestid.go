@@ -0,0 +1,82 @@
+// Package requestid carries the ID that correlates everything done on
+// behalf of one request: log lines, database queries and outbound calls.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header is the HTTP header carrying the request ID, on both incoming and
+// outgoing requests.
+const Header = "X-Request-ID"
+
+// maxLength bounds request IDs accepted from clients.
+const maxLength = 128
+
+type contextKey struct{}
+
+// New returns a random request ID.
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Valid reports whether id, typically received from a client or an
+// upstream proxy, is safe to reuse: non-empty, at most 128 characters and
+// printable ASCII only, so it cannot break log lines or headers.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or "" if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Transport is an http.RoundTripper that forwards the request ID found in
+// the outgoing request's context in the Header header, so downstream
+// services can log the same ID:
+//
+//	client := &http.Client{Transport: &requestid.Transport{}}
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	client.Do(req)
+type Transport struct {
+	// Base performs the request; http.DefaultTransport when nil.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	id := FromContext(req.Context())
+	if id == "" || req.Header.Get(Header) != "" {
+		return base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, id)
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,149 @@
+package router
+
+import (
+	"net/http"
+	"time"
+
+	"gin-template/docs"
+	"gin-template/pkg/config"
+	"gin-template/pkg/health"
+	"gin-template/pkg/metrics"
+	"gin-template/pkg/middleware"
+	"gin-template/pkg/requestid"
+	"gin-template/pkg/version"
+
+	"github.com/gin-gonic/gin"
+	swaggerfiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// NewAdmin builds the router for the admin listener, which serves the
+// operational endpoints in place of the public router.
+func NewAdmin(cfg *config.Provider, opts ...Option) *gin.Engine {
+	o := newOptions(opts)
+
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.AccessLog(cfg), gin.Recovery(), middleware.Version())
+
+	registerOps(r, cfg, o)
+
+	return r
+}
+
+// probe runs the checks of kind and reports each of them, failing with 503
+// when one fails or ready() is false.
+func probe(checks *health.Registry, kind health.Kind, ready func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checks.Run(c.Request.Context(), kind)
+		if !ready() {
+			report.Status = health.StatusFail
+			report.Checks = append(report.Checks, health.Result{
+				Name:      "shutdown",
+				Status:    health.StatusFail,
+				Error:     "shutting down",
+				CheckedAt: time.Now(),
+			})
+		}
+
+		if report.Status != health.StatusPass {
+			c.JSON(http.StatusServiceUnavailable, middleware.Response{
+				Code:      http.StatusServiceUnavailable,
+				Message:   "unhealthy",
+				Data:      report,
+				RequestID: requestid.FromContext(c.Request.Context()),
+			})
+			return
+		}
+		middleware.SuccessResponse(c, report)
+	}
+}
+
+// registerOps adds the health, readiness, build info, metrics,
+// documentation, log level and debug endpoints to r.
+func registerOps(r *gin.Engine, cfg *config.Provider, o options) {
+	// Swagger documentation
+	docs.SwaggerInfo.BasePath = "/api/v1"
+	docs.SwaggerInfo.Version = version.Get().Version
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+
+	// Liveness and readiness probes; /health and /ready are kept for
+	// existing probe configurations
+	livez := probe(o.health, health.Liveness, func() bool { return true })
+	readyz := probe(o.health, health.Readiness, o.ready)
+	r.GET("/livez", livez)
+	r.GET("/health", livez)
+	r.GET("/readyz", readyz)
+	r.GET("/ready", readyz)
+
+	// Build information
+	r.GET("/version", func(c *gin.Context) {
+		middleware.SuccessResponse(c, version.Get())
+	})
+
+	// Prometheus metrics
+	if cfg.Get().Server.Metrics.Enabled {
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+
+	// Runtime log level control, authenticated with the admin token
+	logLevel := r.Group("/loglevel", middleware.AdminAuth(cfg))
+	{
+		logLevel.GET("", getLogLevel)
+		bind(logLevel, http.MethodPut, "", setLogLevel, (*logLevelRequest)(nil))
+	}
+
+	// Profiling and runtime diagnostics, authenticated and off by default
+	registerDebug(r, cfg, o)
+}
+
+// logLevelRequest is the body of PUT /loglevel.
+type logLevelRequest struct {
+	// Level is any level accepted in LogConfig; with a component, empty
+	// removes its override.
+	Level string `json:"level" binding:"required_without=Component"`
+	// Component is the logger to change (see config.GetLogger); empty
+	// changes the base level.
+	Component string `json:"component"`
+	// TTL, e.g. "15m", restores the previous level after this long.
+	TTL string `json:"ttl"`
+}
+
+// logLevelResponse reports the base level, per-component overrides and
+// how many events sampling has dropped.
+type logLevelResponse struct {
+	Level         string            `json:"level"`
+	Components    map[string]string `json:"components"`
+	DroppedEvents uint64            `json:"dropped_events"`
+}
+
+func currentLogLevels() logLevelResponse {
+	base, components := config.LogLevels()
+	return logLevelResponse{Level: base, Components: components, DroppedEvents: config.DroppedLogEvents()}
+}
+
+func getLogLevel(c *gin.Context) {
+	middleware.SuccessResponse(c, currentLogLevels())
+}
+
+func setLogLevel(c *gin.Context, req logLevelRequest) {
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			middleware.ErrorResponse(c, http.StatusBadRequest, "ttl must be a positive duration such as 15m")
+			return
+		}
+	}
+
+	if err := config.SetComponentLogLevel(req.Component, req.Level, ttl); err != nil {
+		middleware.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger := config.GetLogger("admin")
+	logger.Warn().
+		Str("log_component", req.Component).
+		Str("log_level", req.Level).
+		Dur("ttl", ttl).
+		Msg("Log level changed at runtime")
+	middleware.SuccessResponse(c, currentLogLevels())
+}
@@ -0,0 +1,219 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	runtimepprof "runtime/pprof"
+	"strconv"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/database"
+	"gin-template/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerDebug adds the profiling and runtime diagnostics endpoints to r
+// under /debug. They require the admin token and answer 404 while
+// Server.Debug is disabled.
+func registerDebug(r *gin.Engine, cfg *config.Provider, o options) {
+	debugRoutes := r.Group("/debug", debugEnabled(cfg), middleware.AdminAuth(cfg))
+
+	// net/http/pprof, for go tool pprof
+	pprofRoutes := debugRoutes.Group("/pprof")
+	{
+		pprofRoutes.GET("/", gin.WrapF(pprof.Index))
+		pprofRoutes.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		pprofRoutes.GET("/profile", limitSeconds(cfg, 30), gin.WrapF(pprof.Profile))
+		pprofRoutes.GET("/symbol", gin.WrapF(pprof.Symbol))
+		pprofRoutes.POST("/symbol", gin.WrapF(pprof.Symbol))
+		pprofRoutes.GET("/trace", limitSeconds(cfg, 1), gin.WrapF(pprof.Trace))
+		pprofRoutes.GET("/:profile", func(c *gin.Context) {
+			pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+		})
+	}
+
+	debugRoutes.GET("/goroutines", goroutineDump)
+	debugRoutes.GET("/gc", gcStats)
+	debugRoutes.GET("/heap", heapSnapshot)
+	debugRoutes.GET("/cpu", cpuProfile(cfg))
+	if o.cluster != nil {
+		debugRoutes.GET("/db", poolStats(o.cluster))
+	}
+}
+
+// debugEnabled hides the debug endpoints while they are disabled. It runs
+// before authentication so their existence is not revealed either.
+func debugEnabled(cfg *config.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.Get().Server.Debug.Enabled {
+			middleware.ErrorResponse(c, http.StatusNotFound, "not found")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// profileSeconds parses the "seconds" query parameter, defaulting to def,
+// and checks it against the configured maximum profile duration.
+func profileSeconds(c *gin.Context, cfg *config.Provider, def int) (time.Duration, bool) {
+	limit := cfg.Get().Server.Debug.MaxProfileDuration
+	seconds, err := strconv.Atoi(c.DefaultQuery("seconds", strconv.Itoa(def)))
+	if err != nil || seconds <= 0 || time.Duration(seconds)*time.Second > limit {
+		middleware.ErrorResponse(c, http.StatusBadRequest,
+			fmt.Sprintf("seconds must be between 1 and %d", int(limit.Seconds())))
+		c.Abort()
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// limitSeconds applies the maximum profile duration to the pprof
+// endpoints that record for "seconds", def being the handler's default.
+// The validated value is written back to the query, so the handler never
+// falls back to a default of its own.
+func limitSeconds(cfg *config.Provider, def int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		seconds, ok := profileSeconds(c, cfg, def)
+		if !ok {
+			return
+		}
+		query := c.Request.URL.Query()
+		query.Set("seconds", strconv.Itoa(int(seconds.Seconds())))
+		c.Request.URL.RawQuery = query.Encode()
+		c.Next()
+	}
+}
+
+// attachment makes the response a file download named
+// "<name>-<timestamp>.pb.gz".
+func attachment(c *gin.Context, name string) {
+	filename := fmt.Sprintf("%s-%s.pb.gz", name, time.Now().UTC().Format("20060102T150405Z"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+}
+
+// goroutineDump writes the stack of every goroutine, as in a crash.
+func goroutineDump(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	_ = runtimepprof.Lookup("goroutine").WriteTo(c.Writer, 2)
+}
+
+// gcStatsResponse summarises garbage collection and heap usage.
+type gcStatsResponse struct {
+	NumGC            int64     `json:"num_gc"`
+	LastGC           time.Time `json:"last_gc"`
+	PauseTotalMS     float64   `json:"pause_total_ms"`
+	RecentPausesMS   []float64 `json:"recent_pauses_ms"`
+	GCCPUFraction    float64   `json:"gc_cpu_fraction"`
+	HeapAllocBytes   uint64    `json:"heap_alloc_bytes"`
+	HeapInuseBytes   uint64    `json:"heap_inuse_bytes"`
+	HeapSysBytes     uint64    `json:"heap_sys_bytes"`
+	HeapObjects      uint64    `json:"heap_objects"`
+	NextGCBytes      uint64    `json:"next_gc_bytes"`
+	MemoryLimitBytes int64     `json:"memory_limit_bytes"`
+	Goroutines       int       `json:"goroutines"`
+	GOMAXPROCS       int       `json:"gomaxprocs"`
+}
+
+// gcStats reports garbage collection statistics, with the ten most recent
+// pauses.
+func gcStats(c *gin.Context) {
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	// ReadGCStats returns up to 256 pauses, most recent first
+	gc.Pause = gc.Pause[:min(len(gc.Pause), 10)]
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	pauses := make([]float64, 0, len(gc.Pause))
+	for _, pause := range gc.Pause {
+		pauses = append(pauses, float64(pause.Microseconds())/1000)
+	}
+
+	middleware.SuccessResponse(c, gcStatsResponse{
+		NumGC:            gc.NumGC,
+		LastGC:           gc.LastGC,
+		PauseTotalMS:     float64(gc.PauseTotal.Microseconds()) / 1000,
+		RecentPausesMS:   pauses,
+		GCCPUFraction:    mem.GCCPUFraction,
+		HeapAllocBytes:   mem.HeapAlloc,
+		HeapInuseBytes:   mem.HeapInuse,
+		HeapSysBytes:     mem.HeapSys,
+		HeapObjects:      mem.HeapObjects,
+		NextGCBytes:      mem.NextGC,
+		MemoryLimitBytes: debug.SetMemoryLimit(-1),
+		Goroutines:       runtime.NumGoroutine(),
+		GOMAXPROCS:       runtime.GOMAXPROCS(0),
+	})
+}
+
+// heapSnapshot downloads a heap profile for go tool pprof. With ?gc=1 a
+// garbage collection runs first so the profile is up to date.
+func heapSnapshot(c *gin.Context) {
+	if c.Query("gc") == "1" {
+		runtime.GC()
+	}
+
+	var buf bytes.Buffer
+	if err := runtimepprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+		middleware.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	attachment(c, "heap")
+	c.Data(http.StatusOK, "application/octet-stream", buf.Bytes())
+}
+
+// cpuProfile records a CPU profile for ?seconds (default 10) and downloads
+// it for go tool pprof. Only one CPU profile can run at a time.
+func cpuProfile(cfg *config.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		duration, ok := profileSeconds(c, cfg, 10)
+		if !ok {
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := runtimepprof.StartCPUProfile(&buf); err != nil {
+			middleware.ErrorResponse(c, http.StatusConflict, "a CPU profile is already being recorded")
+			return
+		}
+
+		// The profile may outlast the server's write timeout
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(duration + 10*time.Second))
+
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-c.Request.Context().Done():
+		}
+		runtimepprof.StopCPUProfile()
+		if c.Request.Context().Err() != nil {
+			return
+		}
+
+		attachment(c, "cpu")
+		c.Data(http.StatusOK, "application/octet-stream", buf.Bytes())
+	}
+}
+
+// poolStats reports the database connection pools, as exported to
+// Prometheus, for a quick look without a dashboard.
+func poolStats(cluster *database.Cluster) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats, err := cluster.Stats()
+		if err != nil {
+			middleware.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		middleware.SuccessResponse(c, stats)
+	}
+}
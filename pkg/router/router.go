@@ -1,76 +1,122 @@
 package router
 
 import (
-	"gin-template/docs"
+	"net/http"
+
+	"gin-template/pkg/config"
 	"gin-template/pkg/controller"
+	"gin-template/pkg/database"
+	"gin-template/pkg/health"
 	"gin-template/pkg/middleware"
 	"gin-template/pkg/models"
 	"gin-template/pkg/service"
 
 	"github.com/gin-gonic/gin"
-	swaggerfiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 	"gorm.io/gorm"
 )
 
-func New(db *gorm.DB) *gin.Engine {
-	// Create Gin engine
-	r := gin.Default()
+// Option customises the router built by New.
+type Option func(*options)
+
+type options struct {
+	ready   func() bool
+	health  *health.Registry
+	cluster *database.Cluster
+}
+
+// WithReadiness makes /readyz fail whenever ready() is false. It is
+// typically lifecycle.Lifecycle.Ready, which fails during shutdown.
+func WithReadiness(ready func() bool) Option {
+	return func(o *options) {
+		o.ready = ready
+	}
+}
+
+// WithHealth makes /livez and /readyz run the checks of checks; without
+// it they only report whether the process is up and not shutting down.
+func WithHealth(checks *health.Registry) Option {
+	return func(o *options) {
+		o.health = checks
+	}
+}
+
+// WithCluster makes the API read from the replicas of cluster, whose
+// primary is the database given to New, and /debug/db report its
+// connection pools. New uses its database alone by default.
+func WithCluster(cluster *database.Cluster) Option {
+	return func(o *options) {
+		o.cluster = cluster
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		ready:  func() bool { return true },
+		health: health.NewRegistry(0, 0),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func New(db *gorm.DB, cfg *config.Provider, opts ...Option) *gin.Engine {
+	o := newOptions(append([]Option{WithCluster(database.NewCluster(db))}, opts...))
 
-	// Global middleware
-	r.Use(middleware.CORS())
+	// Create Gin engine; requests are logged through zerolog rather than
+	// gin's text logger
+	r := gin.New()
+
+	// Global middleware; metrics wrap Recovery and CORS so panics and
+	// aborted preflights are counted
+	r.Use(middleware.RequestID(), middleware.Tracing())
+	if cfg.Get().Server.Metrics.Enabled {
+		r.Use(middleware.Metrics())
+	}
+	r.Use(middleware.AccessLog(cfg), gin.Recovery(), middleware.Version(), middleware.CORS(cfg))
 
 	// Initialize services
-	userService := service.NewUserService(db)
+	userService := service.NewUserService(o.cluster)
 
 	// Initialize controllers
 	userController := controller.NewUserController(userService)
 
 	// API route group
 	api := r.Group("/api/v1")
+	api.Use(middleware.RateLimit(cfg), middleware.ReadYourWrites())
 
 	// User routes - using new middleware architecture
 	userRoutes := api.Group("/users")
 	{
 		// POST /users - create user, auto-bind JSON body
-		userRoutes.POST("",
-			middleware.BindAndCall(
-				userController.CreateUser,
-				(*models.CreateUserRequest)(nil),
-			))
+		bind(userRoutes, http.MethodPost, "",
+			userController.CreateUser,
+			(*models.CreateUserRequest)(nil),
+		)
 
 		// GET /users - get user list, auto-bind query parameters
-		userRoutes.GET("",
-			middleware.BindAndCall(
-				userController.GetUsers,
-				(*models.GetUsersQuery)(nil),
-			))
+		bind(userRoutes, http.MethodGet, "",
+			userController.GetUsers,
+			(*models.GetUsersQuery)(nil),
+		)
 
 		// GET /users/:id - get single user
 		userRoutes.GET("/:id", userController.GetUser)
 
 		// PUT /users/:id - update user, auto-bind JSON body
-		userRoutes.PUT("/:id",
-			middleware.BindAndCall(
-				userController.UpdateUser,
-				(*models.UpdateUserRequest)(nil),
-			))
+		bind(userRoutes, http.MethodPut, "/:id",
+			userController.UpdateUser,
+			(*models.UpdateUserRequest)(nil),
+		)
 
 		// DELETE /users/:id - delete user
 		userRoutes.DELETE("/:id", userController.DeleteUser)
 	}
 
-	// Swagger documentation
-	docs.SwaggerInfo.BasePath = "/api/v1"
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
-
-	// Health check
-	r.GET("/health", func(c *gin.Context) {
-		middleware.SuccessResponse(c, gin.H{
-			"status":  "healthy",
-			"message": "API is running",
-		})
-	})
+	// Operational endpoints move to the admin listener when it is enabled
+	if !cfg.Get().Server.Admin.Enabled {
+		registerOps(r, cfg, o)
+	}
 
 	return r
 }
@@ -0,0 +1,86 @@
+package router
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"gin-template/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Method  string   `json:"method"`
+	Path    string   `json:"path"`
+	Handler string   `json:"handler"`
+	Binds   []string `json:"binds,omitempty"`
+}
+
+// binding is what bind records about a BindAndCall route.
+type binding struct {
+	handler string
+	types   []string
+}
+
+// bindings maps "METHOD /full/path" to the binding registered by bind.
+var bindings sync.Map
+
+// bind registers handler on group through middleware.BindAndCall and
+// records the bound request types so Routes can report them.
+func bind(group *gin.RouterGroup, method, path string, handler middleware.Handler, bindTypes ...any) {
+	group.Handle(method, path, middleware.BindAndCall(handler, bindTypes...))
+
+	types := make([]string, 0, len(bindTypes))
+	for _, t := range bindTypes {
+		types = append(types, reflect.TypeOf(t).Elem().String())
+	}
+	bindings.Store(method+" "+strings.TrimSuffix(group.BasePath(), "/")+path, binding{
+		handler: funcName(reflect.ValueOf(handler).Pointer()),
+		types:   types,
+	})
+}
+
+// Routes lists the routes registered on r, sorted by path and method. For
+// routes registered through BindAndCall the controller method and the
+// bound request types are reported instead of the generic wrapper.
+func Routes(r *gin.Engine) []RouteInfo {
+	var routes []RouteInfo
+	for _, route := range r.Routes() {
+		info := RouteInfo{
+			Method:  route.Method,
+			Path:    route.Path,
+			Handler: shortName(route.Handler),
+		}
+		if b, ok := bindings.Load(route.Method + " " + route.Path); ok {
+			info.Handler = b.(binding).handler
+			info.Binds = b.(binding).types
+		}
+		routes = append(routes, info)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+func funcName(pc uintptr) string {
+	return shortName(runtime.FuncForPC(pc).Name())
+}
+
+// shortName trims the module path and the method value suffix from a
+// function name, e.g. "controller.(*UserController).GetUser".
+func shortName(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gin-template/pkg/config"
+)
+
+// listen opens every public listener configured in cfg. If one fails, the
+// ones already opened are closed.
+func listen(cfg config.ServerConfig) ([]net.Listener, error) {
+	addrs := cfg.Listen
+	if len(addrs) == 0 {
+		addrs = []string{"tcp://:" + cfg.Port}
+	}
+
+	var listeners []net.Listener
+	for _, addr := range addrs {
+		opened, err := listenAddress(addr, cfg.UnixSocket)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, opened...)
+	}
+	return listeners, nil
+}
+
+func listenAddress(addr string, sock config.UnixSocketConfig) ([]net.Listener, error) {
+	network, address, err := config.ParseListenAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch network {
+	case config.NetworkUnix:
+		l, err := listenUnix(address, sock)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	case config.NetworkSystemd:
+		return systemdListeners(address)
+	default:
+		l, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+}
+
+// listenUnix creates a Unix domain socket at path with the configured mode
+// and ownership. A stale socket left by a previous run is removed first.
+func listenUnix(path string, sock config.UnixSocketConfig) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("unix socket %s: file exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale unix socket: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applySocketPermissions(path, sock); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("unix socket %s: %w", path, err)
+	}
+	return l, nil
+}
+
+func applySocketPermissions(path string, sock config.UnixSocketConfig) error {
+	if sock.Mode != "" {
+		mode, err := strconv.ParseUint(sock.Mode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid mode %q", sock.Mode)
+		}
+		if err := os.Chmod(path, fs.FileMode(mode)); err != nil {
+			return err
+		}
+	}
+
+	if sock.Owner == "" && sock.Group == "" {
+		return nil
+	}
+
+	uid, gid := -1, -1
+	if sock.Owner != "" {
+		id, err := lookupID(sock.Owner, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		})
+		if err != nil {
+			return fmt.Errorf("owner %q: %w", sock.Owner, err)
+		}
+		uid = id
+	}
+	if sock.Group != "" {
+		id, err := lookupID(sock.Group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		})
+		if err != nil {
+			return fmt.Errorf("group %q: %w", sock.Group, err)
+		}
+		gid = id
+	}
+	return os.Chown(path, uid, gid)
+}
+
+// lookupID accepts a numeric ID or resolves a name with lookup.
+func lookupID(nameOrID string, lookup func(string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		return id, nil
+	}
+	id, err := lookup(nameOrID)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(id)
+}
+
+// systemdListenFDsStart is the first file descriptor passed by systemd.
+const systemdListenFDsStart = 3
+
+var (
+	systemdOnce    sync.Once
+	systemdSockets map[string][]net.Listener
+	systemdAll     []net.Listener
+	systemdErr     error
+)
+
+// systemdListeners returns the sockets passed through systemd socket
+// activation (LISTEN_FDS), all of them when name is empty or those whose
+// FileDescriptorName matches name.
+func systemdListeners(name string) ([]net.Listener, error) {
+	systemdOnce.Do(func() {
+		systemdAll, systemdSockets, systemdErr = inheritSystemdSockets()
+	})
+	if systemdErr != nil {
+		return nil, systemdErr
+	}
+
+	if name == "" {
+		if len(systemdAll) == 0 {
+			return nil, errors.New("systemd socket activation: no sockets passed (LISTEN_FDS is not set)")
+		}
+		return systemdAll, nil
+	}
+	if len(systemdSockets[name]) == 0 {
+		return nil, fmt.Errorf("systemd socket activation: no socket named %q", name)
+	}
+	return systemdSockets[name], nil
+}
+
+// inheritSystemdSockets implements the sd_listen_fds protocol. The
+// environment variables are cleared so child processes do not inherit them.
+func inheritSystemdSockets() ([]net.Listener, map[string][]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil, nil
+	}
+
+	var names []string
+	if value := os.Getenv("LISTEN_FDNAMES"); value != "" {
+		names = strings.Split(value, ":")
+	}
+
+	var all []net.Listener
+	byName := make(map[string][]net.Listener)
+	for i := 0; i < count; i++ {
+		fd := systemdListenFDsStart + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) {
+			name = names[i]
+		}
+
+		file := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("systemd socket %s (fd %d): %w", name, fd, err)
+		}
+		all = append(all, l)
+		byName[name] = append(byName[name], l)
+	}
+	return all, byName, nil
+}
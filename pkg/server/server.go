@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/lifecycle"
+
+	"github.com/rs/zerolog"
+)
+
+// Server is an http.Server configured from config.ServerConfig, plus the
+// optional admin listener.
+type Server struct {
+	cfg    config.ServerConfig
+	http   *http.Server
+	admin  *http.Server
+	certs  *certReloader
+	logger zerolog.Logger
+}
+
+// Option customises a Server.
+type Option func(*Server)
+
+// WithAdmin serves handler on the admin listener when it is enabled in
+// ServerConfig.Admin.
+func WithAdmin(handler http.Handler) Option {
+	return func(s *Server) {
+		if !s.cfg.Admin.Enabled {
+			return
+		}
+		s.admin = &http.Server{
+			Addr:              net.JoinHostPort(s.cfg.Admin.Host, s.cfg.Admin.Port),
+			Handler:           handler,
+			ReadHeaderTimeout: s.cfg.ReadHeaderTimeout,
+			IdleTimeout:       s.cfg.IdleTimeout,
+			MaxHeaderBytes:    s.cfg.MaxHeaderBytes,
+		}
+	}
+}
+
+// New creates a Server serving handler. When TLS is enabled the
+// certificate, key and client CA bundle are loaded immediately so
+// configuration mistakes surface before the server starts.
+func New(cfg config.ServerConfig, handler http.Handler, opts ...Option) (*Server, error) {
+	s := &Server{
+		cfg: cfg,
+		http: &http.Server{
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		},
+		logger: config.GetLogger("server"),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if cfg.TLS.Enabled {
+		certs, err := newCertReloader(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		s.certs = certs
+		s.http.TLSConfig = certs.tlsConfig()
+	}
+
+	return s, nil
+}
+
+// ListenAndServe serves the public listener, and the admin listener if
+// configured, until the server is shut down. It returns the first error
+// from either listener, or nil once both have been shut down.
+func (s *Server) ListenAndServe() error {
+	var wg sync.WaitGroup
+	errc := make(chan error, 2)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := s.servePublic(); err != nil {
+			errc <- err
+		}
+	}()
+
+	if s.admin != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.logger.Info().Str("addr", s.admin.Addr).Msg("Serving admin endpoints")
+			if err := s.admin.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errc <- fmt.Errorf("admin listener: %w", err)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(errc)
+	}()
+	return <-errc
+}
+
+// servePublic serves HTTP, or HTTPS when TLS is enabled, on every public
+// listener: the TCP port by default, or the addresses in ServerConfig.Listen.
+func (s *Server) servePublic() error {
+	listeners, err := listen(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	scheme := "HTTP"
+	if s.certs != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.certs.watch(ctx)
+		scheme = "HTTPS"
+	}
+
+	var wg sync.WaitGroup
+	errc := make(chan error, len(listeners))
+	for _, l := range listeners {
+		event := s.logger.Info().
+			Str("network", l.Addr().Network()).
+			Str("addr", l.Addr().String())
+		if s.certs != nil {
+			event = event.Str("client_auth", s.cfg.TLS.ClientAuth)
+		}
+		event.Msg("Serving " + scheme)
+
+		wg.Add(1)
+		go func(l net.Listener) {
+			defer wg.Done()
+			var err error
+			if s.certs != nil {
+				// Certificates come from TLSConfig, so no files are passed here.
+				err = s.http.ServeTLS(l, "", "")
+			} else {
+				err = s.http.Serve(l)
+			}
+			if !errors.Is(err, http.ErrServerClosed) {
+				errc <- err
+			}
+		}(l)
+	}
+
+	go func() {
+		wg.Wait()
+		close(errc)
+	}()
+	return <-errc
+}
+
+// Shutdown stops the public listener and then the admin listener, so
+// readiness stays observable while requests drain; see
+// http.Server.Shutdown.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.http.Shutdown(ctx)
+	if s.admin != nil {
+		err = errors.Join(err, s.admin.Shutdown(ctx))
+	}
+	return err
+}
+
+// ErrServe is returned by Run when the server stopped on its own, e.g.
+// because its port was already in use.
+var ErrServe = errors.New("server stopped unexpectedly")
+
+// ErrDrainTimeout is returned by Run when in-flight requests did not finish
+// within the shutdown timeout and their connections were closed.
+var ErrDrainTimeout = errors.New("in-flight requests did not drain before the shutdown timeout")
+
+// Run serves until ctx is cancelled and then shuts down gracefully:
+// readiness starts failing, the server keeps serving for ShutdownDelay,
+// stops accepting connections and waits up to ShutdownTimeout for
+// in-flight requests. Finally lc's shutdown hooks run with their own
+// ShutdownTimeout.
+//
+// The returned error wraps ErrServe if the server failed, ErrDrainTimeout
+// if requests were cut off, and a *lifecycle.HookError if hooks failed.
+func (s *Server) Run(ctx context.Context, lc *lifecycle.Lifecycle) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.ListenAndServe()
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		// One listener failed; stop the other one too.
+		lc.BeginShutdown()
+		s.close()
+		err = fmt.Errorf("%w: %w", ErrServe, err)
+	case <-ctx.Done():
+		lc.BeginShutdown()
+		s.logger.Info().
+			Dur("delay", s.cfg.ShutdownDelay).
+			Dur("timeout", s.cfg.ShutdownTimeout).
+			Msg("Shutting down, readiness is now failing")
+
+		time.Sleep(s.cfg.ShutdownDelay)
+		err = s.drain()
+	}
+
+	hookCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	return errors.Join(err, lc.RunHooks(hookCtx))
+}
+
+// drain stops accepting connections and waits for in-flight requests,
+// force-closing whatever is left at the deadline.
+func (s *Server) drain() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := s.Shutdown(ctx); err != nil {
+		s.logger.Warn().Err(err).Msg("Drain deadline exceeded, closing remaining connections")
+		s.close()
+		return ErrDrainTimeout
+	}
+	s.logger.Info().Dur("took", time.Since(start)).Msg("In-flight requests drained")
+	return nil
+}
+
+// close immediately closes every listener and connection.
+func (s *Server) close() {
+	_ = s.http.Close()
+	if s.admin != nil {
+		_ = s.admin.Close()
+	}
+}
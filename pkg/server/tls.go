@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"gin-template/pkg/config"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// certReloadDebounce coalesces the events produced when a certificate and
+// key are rotated together.
+const certReloadDebounce = 500 * time.Millisecond
+
+// certBundle is the TLS material in use at a point in time.
+type certBundle struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// certReloader serves the current certificate and client CA pool and
+// swaps them when the files on disk change.
+type certReloader struct {
+	cfg     config.TLSConfig
+	current atomic.Pointer[certBundle]
+}
+
+func newCertReloader(cfg config.TLSConfig) (*certReloader, error) {
+	r := &certReloader{cfg: cfg}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload reads the certificate, key and CA bundle from disk. On error the
+// previous bundle stays in use.
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+
+	bundle := &certBundle{cert: &cert}
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("client CA bundle contains no PEM certificates")
+		}
+		bundle.clientCAs = pool
+	}
+
+	r.current.Store(bundle)
+	return nil
+}
+
+// tlsConfig returns a tls.Config that resolves the certificate and client
+// CAs per handshake, so reloads apply to new connections immediately.
+//
+// GetCertificate is set on the outer config even though the per-client
+// config carries the certificate: before Go 1.23, http.Server.ServeTLS
+// only skips loading the (empty) file names when Certificates or
+// GetCertificate is set. TestServerMutualTLS covers this on the oldest Go
+// version in the CI matrix.
+func (r *certReloader) tlsConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: clientAuthType(r.cfg.ClientAuth),
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if r.cfg.MinVersion == "1.3" {
+		base.MinVersion = tls.VersionTLS13
+	}
+
+	return &tls.Config{
+		MinVersion: base.MinVersion,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.current.Load().cert, nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			bundle := r.current.Load()
+			cfg := base.Clone()
+			cfg.Certificates = []tls.Certificate{*bundle.cert}
+			cfg.ClientCAs = bundle.clientCAs
+			return cfg, nil
+		},
+	}
+}
+
+// watch reloads the TLS material whenever one of its files changes, until
+// ctx is done.
+func (r *certReloader) watch(ctx context.Context) {
+	logger := config.GetLogger("server")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Error().Err(err).Msg("Cannot watch TLS files, certificate reload disabled")
+		return
+	}
+	defer watcher.Close()
+
+	files := []string{filepath.Clean(r.cfg.CertFile), filepath.Clean(r.cfg.KeyFile)}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, filepath.Clean(r.cfg.ClientCAFile))
+	}
+
+	// Watch directories so replaced files (e.g. Kubernetes secret updates
+	// or certbot renewals) are still seen.
+	var dirs []string
+	for _, f := range files {
+		if dir := filepath.Dir(f); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+			if err := watcher.Add(dir); err != nil {
+				logger.Error().Err(err).Str("dir", dir).Msg("Cannot watch TLS directory")
+			}
+		}
+	}
+
+	debounce := time.NewTimer(certReloadDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-watcher.Events:
+			if slices.Contains(files, filepath.Clean(event.Name)) {
+				debounce.Reset(certReloadDebounce)
+			}
+		case <-debounce.C:
+			if err := r.reload(); err != nil {
+				logger.Error().Err(err).Msg("TLS reload failed, keeping previous certificate")
+				continue
+			}
+			logger.Info().Msg("TLS certificate reloaded")
+		case err := <-watcher.Errors:
+			logger.Error().Err(err).Msg("TLS file watcher error")
+		}
+	}
+}
+
+func clientAuthType(mode string) tls.ClientAuthType {
+	switch mode {
+	case "optional":
+		return tls.VerifyClientCertIfGiven
+	case "require":
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.NoClientCert
+	}
+}
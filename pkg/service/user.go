@@ -1,20 +1,32 @@
 package service
 
 import (
+	"context"
+
+	"gin-template/pkg/database"
 	"gin-template/pkg/models"
+	"gin-template/pkg/tracing"
 
-	"gorm.io/gorm"
+	"github.com/rs/zerolog"
 )
 
 type UserService struct {
-	db *gorm.DB
+	db *database.Cluster
 }
 
-func NewUserService(db *gorm.DB) *UserService {
+// NewUserService returns a UserService reading from the replicas of db,
+// if any, and writing to its primary.
+func NewUserService(db *database.Cluster) *UserService {
 	return &UserService{db: db}
 }
 
-func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
+// CreateUser, like every UserService method, runs its queries in ctx,
+// traces them under a span named after the method and logs through the
+// request logger stored in ctx, if any.
+func (s *UserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
 	user := &models.User{
 		Name:  req.Name,
 		Email: req.Email,
@@ -22,26 +34,33 @@ func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, e
 		Phone: req.Phone,
 	}
 
-	if err := s.db.Create(user).Error; err != nil {
-		return nil, err
+	if err := s.db.Write(ctx).Create(user).Error; err != nil {
+		return nil, tracing.Fail(span, err)
 	}
 
+	zerolog.Ctx(ctx).Info().Uint("user_id", user.ID).Msg("User created")
 	return user, nil
 }
 
-func (s *UserService) GetUser(id uint) (*models.User, error) {
+func (s *UserService) GetUser(ctx context.Context, id uint) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUser")
+	defer span.End()
+
 	var user models.User
-	if err := s.db.First(&user, id).Error; err != nil {
-		return nil, err
+	if err := s.db.Read(ctx).First(&user, id).Error; err != nil {
+		return nil, tracing.Fail(span, err)
 	}
 	return &user, nil
 }
 
-func (s *UserService) GetUsers(req *models.GetUsersQuery) ([]models.User, int64, error) {
+func (s *UserService) GetUsers(ctx context.Context, req *models.GetUsersQuery) ([]models.User, int64, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUsers")
+	defer span.End()
+
 	var users []models.User
 	var total int64
 
-	query := s.db.Model(&models.User{})
+	query := s.db.Read(ctx).Model(&models.User{})
 
 	// Add filtering conditions
 	if req.Name != "" {
@@ -53,7 +72,7 @@ func (s *UserService) GetUsers(req *models.GetUsersQuery) ([]models.User, int64,
 
 	// Get total count
 	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, tracing.Fail(span, err)
 	}
 
 	// Pagination
@@ -66,16 +85,21 @@ func (s *UserService) GetUsers(req *models.GetUsersQuery) ([]models.User, int64,
 
 	offset := (req.Page - 1) * req.PageSize
 	if err := query.Offset(offset).Limit(req.PageSize).Find(&users).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, tracing.Fail(span, err)
 	}
 
 	return users, total, nil
 }
 
-func (s *UserService) UpdateUser(id uint, req *models.UpdateUserRequest) (*models.User, error) {
+func (s *UserService) UpdateUser(ctx context.Context, id uint, req *models.UpdateUserRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	db := s.db.Write(ctx)
+
 	var user models.User
-	if err := s.db.First(&user, id).Error; err != nil {
-		return nil, err
+	if err := db.First(&user, id).Error; err != nil {
+		return nil, tracing.Fail(span, err)
 	}
 
 	// Only update non-empty fields
@@ -93,13 +117,22 @@ func (s *UserService) UpdateUser(id uint, req *models.UpdateUserRequest) (*model
 		updates["phone"] = *req.Phone
 	}
 
-	if err := s.db.Model(&user).Updates(updates).Error; err != nil {
-		return nil, err
+	if err := db.Model(&user).Updates(updates).Error; err != nil {
+		return nil, tracing.Fail(span, err)
 	}
 
+	zerolog.Ctx(ctx).Info().Uint("user_id", user.ID).Msg("User updated")
 	return &user, nil
 }
 
-func (s *UserService) DeleteUser(id uint) error {
-	return s.db.Delete(&models.User{}, id).Error
+func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	if err := s.db.Write(ctx).Delete(&models.User{}, id).Error; err != nil {
+		return tracing.Fail(span, err)
+	}
+
+	zerolog.Ctx(ctx).Info().Uint("user_id", id).Msg("User deleted")
+	return nil
 }
@@ -0,0 +1,76 @@
+// Package version describes the running build. The variables are set at
+// build time with -ldflags, for example:
+//
+//	go build -ldflags "-X gin-template/pkg/version.Version=v1.2.0 \
+//	    -X gin-template/pkg/version.Commit=$(git rev-parse HEAD) \
+//	    -X gin-template/pkg/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/server
+//
+// When they are not set, the commit and build time fall back to the VCS
+// information the Go toolchain embeds in the binary.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+var (
+	// Version is the release version, e.g. "v1.2.0".
+	Version = "dev"
+	// Commit is the git commit the binary was built from.
+	Commit = ""
+	// BuildTime is when the binary was built, in RFC 3339.
+	BuildTime = ""
+)
+
+// Info is the build information reported by --version and /version.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// String formats i on one line, e.g.
+// "v1.2.0 (commit 1a2b3c4, built 2024-05-01T10:00:00Z, go1.23.4)".
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s, %s)", i.Version, i.Commit, i.BuildTime, i.GoVersion)
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	once.Do(func() {
+		info = Info{
+			Version:   Version,
+			Commit:    Commit,
+			BuildTime: BuildTime,
+			GoVersion: runtime.Version(),
+		}
+
+		if build, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range build.Settings {
+				switch {
+				case s.Key == "vcs.revision" && info.Commit == "":
+					info.Commit = s.Value
+				case s.Key == "vcs.time" && info.BuildTime == "":
+					info.BuildTime = s.Value
+				}
+			}
+		}
+
+		if info.Commit == "" {
+			info.Commit = "unknown"
+		}
+		if info.BuildTime == "" {
+			info.BuildTime = "unknown"
+		}
+	})
+	return info
+}
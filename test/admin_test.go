@@ -0,0 +1,171 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/router"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminListenerTakesOverOpsEndpoints(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Admin.Enabled = true
+	cfg.Server.Admin.Token = testAdminToken
+	cfg.Server.Debug.Enabled = true
+	provider := config.NewProvider(cfg)
+
+	public := router.New(SetupTestDB(), provider)
+	admin := router.NewAdmin(provider)
+
+	for _, path := range []string{"/health", "/ready", "/version", "/swagger/index.html", "/loglevel", "/debug/pprof/"} {
+		w := httptest.NewRecorder()
+		public.ServeHTTP(w, MakeRequest("GET", path, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, "public %s", path)
+	}
+
+	for _, path := range []string{"/health", "/ready", "/loglevel", "/debug/pprof/"} {
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, adminRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, "admin %s", path)
+	}
+}
+
+func TestLogLevelOnPublicRouterWithoutAdmin(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Admin.Token = testAdminToken
+	public := router.New(SetupTestDB(), config.NewProvider(cfg))
+
+	w := httptest.NewRecorder()
+	public.ServeHTTP(w, MakeRequest("GET", "/loglevel", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "the admin token is still required")
+
+	w = httptest.NewRecorder()
+	public.ServeHTTP(w, adminRequest("GET", "/loglevel", nil))
+	AssertStatusOK(t, w)
+}
+
+// testAdminToken 测试用管理令牌
+const testAdminToken = "test-admin-token"
+
+// adminRequest 创建携带管理令牌的请求
+func adminRequest(method, url string, body any) *http.Request {
+	req := MakeRequest(method, url, body)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return req
+}
+
+func newTestAdmin() http.Handler {
+	cfg := config.Default()
+	cfg.Server.Admin.Token = testAdminToken
+	return router.NewAdmin(config.NewProvider(cfg))
+}
+
+func TestAdminLogLevelRequiresToken(t *testing.T) {
+	t.Run("Refused without a configured token", func(t *testing.T) {
+		admin := router.NewAdmin(config.NewProvider(config.Default()))
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, adminRequest("GET", "/loglevel", nil))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Wrong token", func(t *testing.T) {
+		req := MakeRequest("PUT", "/loglevel", map[string]string{"level": "debug"})
+		req.Header.Set("Authorization", "Bearer wrong")
+		w := httptest.NewRecorder()
+		newTestAdmin().ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAdminSetLogLevel(t *testing.T) {
+	t.Cleanup(resetLogger)
+	admin := newTestAdmin()
+
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, adminRequest("PUT", "/loglevel", map[string]string{"level": "warn"}))
+	AssertStatusOK(t, w)
+	assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, adminRequest("PUT", "/loglevel", map[string]string{"level": "loud"}))
+	AssertStatusBadRequest(t, w)
+}
+
+func TestAdminSetComponentLogLevel(t *testing.T) {
+	t.Cleanup(resetLogger)
+	config.SetupLogger(config.LogConfig{Level: "info", Format: "json", Levels: map[string]string{"database": "warn"}})
+	admin := newTestAdmin()
+
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, adminRequest("PUT", "/loglevel", map[string]string{
+		"level": "debug", "component": "middleware", "ttl": "50ms",
+	}))
+	AssertStatusOK(t, w)
+
+	var resp struct {
+		Data struct {
+			Level      string            `json:"level"`
+			Components map[string]string `json:"components"`
+		} `json:"data"`
+	}
+	ParseResponseBody(t, w, &resp)
+	assert.Equal(t, "info", resp.Data.Level)
+	assert.Equal(t, map[string]string{"database": "warn", "middleware": "debug"}, resp.Data.Components)
+
+	assert.Equal(t, zerolog.DebugLevel, config.ComponentLogLevel("middleware"))
+	assert.Equal(t, zerolog.WarnLevel, config.ComponentLogLevel("database"))
+	assert.Equal(t, zerolog.InfoLevel, config.ComponentLogLevel("http"))
+
+	// The override is removed once the TTL has passed
+	assert.Eventually(t, func() bool {
+		return config.ComponentLogLevel("middleware") == zerolog.InfoLevel
+	}, time.Second, 10*time.Millisecond)
+
+	// Spellings accepted by the config file, and clearing an override
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, adminRequest("PUT", "/loglevel", map[string]string{"level": "WARNING", "component": "http"}))
+	AssertStatusOK(t, w)
+	assert.Equal(t, zerolog.WarnLevel, config.ComponentLogLevel("http"))
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, adminRequest("PUT", "/loglevel", map[string]string{"level": "", "component": "database"}))
+	AssertStatusOK(t, w)
+	assert.Equal(t, zerolog.InfoLevel, config.ComponentLogLevel("database"))
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, adminRequest("PUT", "/loglevel", map[string]string{"level": "debug", "ttl": "soon"}))
+	AssertStatusBadRequest(t, w)
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, adminRequest("PUT", "/loglevel", map[string]string{"level": ""}))
+	AssertStatusBadRequest(t, w)
+}
+
+func TestComponentLogLevels(t *testing.T) {
+	logs := captureLogs(t)
+	require.NoError(t, config.SetComponentLogLevel("database", "debug", 0))
+
+	database := config.GetLogger("database")
+	http := config.GetLogger("http")
+	database.Debug().Msg("shown")
+	http.Debug().Msg("hidden")
+	http.Info().Msg("shown")
+
+	// Loggers not built by GetLogger keep the base level
+	log.Debug().Msg("hidden")
+	zerolog.Ctx(context.Background()).Debug().Msg("hidden")
+
+	lines := logs()
+	require.Len(t, lines, 2)
+	assert.Equal(t, "database", lines[0]["component"])
+	assert.Equal(t, "http", lines[1]["component"])
+}
@@ -0,0 +1,186 @@
+package test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gin-template/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile 在临时目录中写入配置文件
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", `
+env: staging
+server:
+  port: 9000
+log:
+  level: warn
+`)
+	writeFile(t, dir, "config.staging.yaml", `
+log:
+  level: debug
+  format: json
+`)
+	t.Setenv("DB_DSN", "from-env.db")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "staging", cfg.Env)
+	assert.Equal(t, "9000", cfg.Server.Port)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, "json", cfg.Log.Format)
+	assert.Equal(t, "sqlite", cfg.Database.Driver)
+	assert.Equal(t, "from-env.db", cfg.Database.DSN.Value())
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.toml", `
+[server]
+port = "7000"
+
+[database]
+driver = "mysql"
+dsn = "user:pass@tcp(localhost:3306)/app"
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "7000", cfg.Server.Port)
+	assert.Equal(t, "mysql", cfg.Database.Driver)
+}
+
+func TestLoadConfigRejectsUnknownKey(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", `
+server:
+  prot: 8080
+`)
+
+	_, err := config.Load(path)
+	assert.ErrorContains(t, err, `unknown key "server.prot"`)
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", `
+server:
+  port: 99999
+database:
+  driver: oracle
+  dsn: ""
+  postgres:
+    timezone: Mars/Olympus
+log:
+  level: loud
+`)
+
+	_, err := config.Load(path)
+
+	var validationErr *config.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Problems, 5)
+	assert.Contains(t, err.Error(), "server.port")
+	assert.Contains(t, err.Error(), "database.driver")
+	assert.Contains(t, err.Error(), "database.dsn")
+	assert.Contains(t, err.Error(), "database.postgres.timezone: must be a time zone")
+	assert.Contains(t, err.Error(), "log.level")
+}
+
+func TestProviderApplyOnlyReloadable(t *testing.T) {
+	provider := config.NewProvider(config.Default())
+
+	var notified *config.Config
+	provider.Subscribe(func(old, new *config.Config) {
+		notified = new
+	})
+
+	next := config.Default()
+	next.Log.Level = "debug"
+	next.Server.CORS.AllowedOrigins = []string{"https://example.com"}
+	next.Server.Port = "9090"
+
+	changed, ignored := provider.Apply(next)
+
+	assert.ElementsMatch(t, []string{"log.level", "server.cors.allowed_origins"}, changed)
+	assert.Equal(t, []string{"server.port"}, ignored)
+	assert.Equal(t, "debug", provider.Get().Log.Level)
+	assert.Equal(t, "8080", provider.Get().Server.Port)
+	assert.Same(t, provider.Get(), notified)
+}
+
+func TestSecretIsRedacted(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.DSN = "user:hunter2@tcp(db:3306)/app"
+
+	data, err := json.Marshal(cfg.Database)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(data), "hunter2")
+	assert.NotContains(t, fmt.Sprintf("%v %+v %#v", cfg, cfg.Database, cfg.Database), "hunter2")
+	assert.Equal(t, "user:hunter2@tcp(db:3306)/app", cfg.Database.DSN.Value())
+}
+
+func TestLoadConfigSecretFromFile(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "db_dsn", "secret.db\n")
+	t.Setenv("DB_DSN_FILE", path)
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+	assert.Equal(t, "secret.db", cfg.Database.DSN.Value())
+
+	t.Setenv("DB_DSN", "other.db")
+	_, err = config.Load("")
+	assert.ErrorContains(t, err, "only one of DB_DSN and DB_DSN_FILE")
+}
+
+func TestLoadConfigEncryptedSecrets(t *testing.T) {
+	key := make([]byte, 32)
+	sealed, err := config.EncryptSecrets(key, []byte("database:\n  dsn: sealed.db\n"))
+	require.NoError(t, err)
+
+	t.Setenv("SECRETS_FILE", writeFile(t, t.TempDir(), "secrets.enc", string(sealed)))
+	t.Setenv("SECRETS_KEY", base64.StdEncoding.EncodeToString(key))
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+	assert.Equal(t, "sealed.db", cfg.Database.DSN.Value())
+
+	t.Setenv("SECRETS_KEY", base64.StdEncoding.EncodeToString(make([]byte, 31)))
+	_, err = config.Load("")
+	assert.Error(t, err)
+}
+
+func TestResolveConfigSources(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", `
+log:
+  level: warn
+`)
+	t.Setenv("PORT", "9000")
+
+	cfg, sources, err := config.Resolve(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "file:"+path, sources.Of("log.level"))
+	assert.Equal(t, "env:PORT", sources.Of("server.port"))
+	assert.Equal(t, config.SourceDefault, sources.Of("log.format"))
+
+	for _, entry := range config.Describe(cfg, sources) {
+		if entry.Key == "database.dsn" {
+			assert.Equal(t, "******", entry.Value)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/database"
+	"gin-template/pkg/router"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseDSNOptions(t *testing.T) {
+	postgres := config.PostgresConfig{SSLMode: "require", SearchPath: "app,public", TimeZone: "Europe/Paris"}
+
+	tests := []struct {
+		name string
+		cfg  config.DatabaseConfig
+		want string
+	}{
+		{
+			name: "Postgres keyword DSN",
+			cfg:  config.DatabaseConfig{Driver: "postgres", DSN: "host=db user=app dbname=app", Postgres: postgres},
+			want: "host=db user=app dbname=app sslmode=require search_path=app,public TimeZone=Europe/Paris",
+		},
+		{
+			name: "Postgres keyword DSN keeps its own settings",
+			cfg: config.DatabaseConfig{
+				Driver:   "postgres",
+				DSN:      "host=db sslmode=disable timezone=UTC",
+				Postgres: config.PostgresConfig{SSLMode: "require", SearchPath: "my schema", TimeZone: "Europe/Paris"},
+			},
+			want: `host=db sslmode=disable timezone=UTC search_path='my schema'`,
+		},
+		{
+			name: "Postgres URL",
+			cfg:  config.DatabaseConfig{Driver: "postgres", DSN: "postgres://app:secret@db:5432/app?sslmode=disable", Postgres: postgres},
+			want: "postgres://app:secret@db:5432/app?TimeZone=Europe%2FParis&search_path=app%2Cpublic&sslmode=disable",
+		},
+		{
+			name: "MySQL",
+			cfg: config.DatabaseConfig{
+				Driver: "mysql",
+				DSN:    "app:secret@tcp(db:3306)/app?parseTime=true",
+				MySQL:  config.MySQLConfig{TLS: "true", TimeZone: "Asia/Tokyo"},
+			},
+			want: "app:secret@tcp(db:3306)/app?parseTime=true&tls=true&loc=Asia%2FTokyo",
+		},
+		{
+			name: "SQLite",
+			cfg:  config.DatabaseConfig{Driver: "sqlite", DSN: "app.db", SQLite: config.SQLiteConfig{BusyTimeout: 5 * time.Second}},
+			want: "app.db?_busy_timeout=5000",
+		},
+		{
+			name: "SQLite DSN keeps its own timeout",
+			cfg:  config.DatabaseConfig{Driver: "sqlite", DSN: "app.db?_timeout=100", SQLite: config.SQLiteConfig{BusyTimeout: 5 * time.Second}},
+			want: "app.db?_timeout=100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, err := database.DSN(tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, dsn)
+		})
+	}
+}
+
+func TestDatabaseUnknownDriver(t *testing.T) {
+	_, err := database.New(context.Background(), config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"})
+	assert.EqualError(t, err, `unknown database driver "sqlite3": want mysql, postgres or sqlite`)
+}
+
+func TestDatabaseConnectRetry(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "not-yet")
+	cfg := config.DatabaseConfig{
+		Driver: "sqlite",
+		DSN:    config.Secret(filepath.Join(dir, "app.db")),
+		Connect: config.ConnectConfig{
+			Timeout:        300 * time.Millisecond,
+			InitialBackoff: 20 * time.Millisecond,
+			MaxBackoff:     50 * time.Millisecond,
+		},
+	}
+
+	t.Run("Gives up after the timeout", func(t *testing.T) {
+		start := time.Now()
+		_, err := database.Connect(context.Background(), cfg)
+		assert.ErrorContains(t, err, "database not reachable after")
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Single attempt without a timeout", func(t *testing.T) {
+		_, err := database.Open(cfg)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "attempts")
+	})
+
+	t.Run("Succeeds once reachable", func(t *testing.T) {
+		cfg.Connect.Timeout = 5 * time.Second
+		time.AfterFunc(100*time.Millisecond, func() { _ = os.Mkdir(dir, 0o755) })
+
+		db, err := database.Connect(context.Background(), cfg)
+		require.NoError(t, err)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		assert.NoError(t, sqlDB.Close())
+	})
+}
+
+func TestDatabasePool(t *testing.T) {
+	cfg := config.DatabaseConfig{
+		Driver:  "sqlite",
+		DSN:     config.Secret(filepath.Join(t.TempDir(), "app.db")),
+		Migrate: "auto",
+		Pool:    config.PoolConfig{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetime: time.Minute},
+	}
+	db, err := database.New(context.Background(), cfg)
+	require.NoError(t, err)
+
+	stats, err := database.NewCluster(db).Stats()
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "main", stats[0].Name)
+	assert.Equal(t, 7, stats[0].MaxOpenConnections)
+	assert.Equal(t, 1, stats[0].OpenConnections)
+
+	// Also served with the debug endpoints
+	provider := config.Default()
+	provider.Server.Admin.Token = testAdminToken
+	provider.Server.Debug.Enabled = true
+	r := router.New(db, config.NewProvider(provider))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, adminRequest("GET", "/debug/db", nil))
+	AssertStatusOK(t, w)
+	assert.Contains(t, w.Body.String(), `"max_open_connections":7`)
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/router"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDebugRouter 创建启用调试接口的路由
+func newDebugRouter() *gin.Engine {
+	cfg := config.Default()
+	cfg.Server.Admin.Token = testAdminToken
+	cfg.Server.Debug.Enabled = true
+	cfg.Server.Debug.MaxProfileDuration = 5 * time.Second
+	return router.New(SetupTestDB(), config.NewProvider(cfg))
+}
+
+func TestDebugEndpointsAccess(t *testing.T) {
+	t.Run("Hidden when disabled", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Admin.Token = testAdminToken
+		r := router.New(SetupTestDB(), config.NewProvider(cfg))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, adminRequest("GET", "/debug/gc", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Requires the admin token", func(t *testing.T) {
+		r := newDebugRouter()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, MakeRequest("GET", "/debug/pprof/", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Enabled by a reload", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Admin.Token = testAdminToken
+		provider := config.NewProvider(cfg)
+		r := router.New(SetupTestDB(), provider)
+
+		next := config.Default()
+		next.Server.Admin.Token = testAdminToken
+		next.Server.Debug.Enabled = true
+		provider.Apply(next)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, adminRequest("GET", "/debug/gc", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestDebugDiagnostics(t *testing.T) {
+	r := newDebugRouter()
+
+	t.Run("Goroutine dump", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, adminRequest("GET", "/debug/goroutines", nil))
+		AssertStatusOK(t, w)
+		assert.Contains(t, w.Body.String(), "TestDebugDiagnostics")
+	})
+
+	t.Run("GC stats", func(t *testing.T) {
+		for range 12 {
+			runtime.GC()
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, adminRequest("GET", "/debug/gc", nil))
+		AssertStatusOK(t, w)
+
+		var body struct {
+			Data map[string]any `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Contains(t, body.Data, "num_gc")
+		assert.Contains(t, body.Data, "heap_alloc_bytes")
+		assert.Greater(t, body.Data["goroutines"], 0.0)
+		assert.Len(t, body.Data["recent_pauses_ms"], 10)
+	})
+
+	t.Run("Heap snapshot", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, adminRequest("GET", "/debug/heap?gc=1", nil))
+		AssertStatusOK(t, w)
+		assert.Regexp(t, `^attachment; filename="heap-\d{8}T\d{6}Z\.pb\.gz"$`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, []byte{0x1f, 0x8b}, w.Body.Bytes()[:2], "gzipped profile")
+	})
+}
+
+func TestDebugCPUProfile(t *testing.T) {
+	r := newDebugRouter()
+
+	t.Run("Duration is bounded", func(t *testing.T) {
+		for _, seconds := range []string{"0", "6", "abc"} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, adminRequest("GET", "/debug/cpu?seconds="+seconds, nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code, seconds)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, adminRequest("GET", "/debug/pprof/profile?seconds=60", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// pprof's own default of 30s is above the limit too
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, adminRequest("GET", "/debug/pprof/profile", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("One profile at a time", func(t *testing.T) {
+		var wg sync.WaitGroup
+		first := httptest.NewRecorder()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.ServeHTTP(first, adminRequest("GET", "/debug/cpu?seconds=1", nil))
+		}()
+
+		// Wait for the first profile to start
+		require.Eventually(t, func() bool {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, adminRequest("GET", "/debug/cpu?seconds=1", nil))
+			return w.Code == http.StatusConflict
+		}, 900*time.Millisecond, 10*time.Millisecond)
+
+		wg.Wait()
+		AssertStatusOK(t, first)
+		assert.Contains(t, first.Header().Get("Content-Disposition"), `filename="cpu-`)
+		assert.Equal(t, []byte{0x1f, 0x8b}, first.Body.Bytes()[:2], "gzipped profile")
+	})
+}
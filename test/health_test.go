@@ -0,0 +1,138 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/database"
+	"gin-template/pkg/health"
+	"gin-template/pkg/router"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probeResult 请求探针并解析健康报告
+func probeResult(t *testing.T, r *gin.Engine, path string) (int, health.Report) {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, MakeRequest("GET", path, nil))
+
+	var body struct {
+		Data health.Report `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body.Data
+}
+
+func TestHealthProbes(t *testing.T) {
+	checks := health.NewRegistry(time.Second, 0)
+	checks.Register("cache", health.CheckerFunc(func(context.Context) error {
+		return errors.New("connection refused")
+	}))
+	worker := health.NewWorker(0)
+	checks.Register("worker", worker, health.AffectsLiveness())
+
+	r := router.New(SetupTestDB(), config.NewProvider(config.Default()), router.WithHealth(checks))
+
+	// Liveness runs only the liveness checks
+	code, report := probeResult(t, r, "/livez")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusPass, report.Status)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "worker", report.Checks[0].Name)
+
+	// Readiness runs every check and reports each of them
+	code, report = probeResult(t, r, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusFail, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "cache", report.Checks[0].Name)
+	assert.Equal(t, health.StatusFail, report.Checks[0].Status)
+	assert.Equal(t, "connection refused", report.Checks[0].Error)
+	assert.Equal(t, health.StatusPass, report.Checks[1].Status)
+	assert.False(t, report.Checks[1].CheckedAt.IsZero())
+
+	worker.Stop(errors.New("queue closed"))
+	code, report = probeResult(t, r, "/health")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "stopped: queue closed", report.Checks[0].Error)
+}
+
+func TestHealthChecksAreCached(t *testing.T) {
+	var runs atomic.Int32
+	checks := health.NewRegistry(time.Second, time.Minute)
+	checks.Register("database", health.CheckerFunc(func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}))
+
+	for range 3 {
+		report := checks.Run(context.Background(), health.Readiness)
+		assert.Equal(t, health.StatusPass, report.Status)
+	}
+	assert.Equal(t, int32(1), runs.Load())
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	checks := health.NewRegistry(time.Second, 0)
+	checks.Register("slow", health.CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), health.WithTimeout(20*time.Millisecond))
+
+	report := checks.Run(context.Background(), health.Readiness)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "timed out", report.Checks[0].Error)
+	assert.GreaterOrEqual(t, report.Checks[0].LatencyMS, 20.0)
+}
+
+func TestDatabaseHealthChecks(t *testing.T) {
+	cfg := config.DatabaseConfig{
+		Driver: "sqlite",
+		DSN:    config.Secret(filepath.Join(t.TempDir(), "app.db")),
+	}
+	db, err := database.New(context.Background(), cfg)
+	require.NoError(t, err)
+
+	t.Run("Healthy", func(t *testing.T) {
+		checks := health.NewRegistry(time.Second, 0)
+		require.NoError(t, database.RegisterHealthChecks(checks, db, cfg, 1))
+
+		report := checks.Run(context.Background(), health.Readiness)
+		assert.Equal(t, health.StatusPass, report.Status)
+		require.Len(t, report.Checks, 2)
+		assert.Equal(t, "database", report.Checks[0].Name)
+		assert.Equal(t, "disk", report.Checks[1].Name)
+	})
+
+	t.Run("Low disk space", func(t *testing.T) {
+		checks := health.NewRegistry(time.Second, 0)
+		require.NoError(t, database.RegisterHealthChecks(checks, db, cfg, 1<<62))
+
+		report := checks.Run(context.Background(), health.Readiness)
+		assert.Equal(t, health.StatusFail, report.Status)
+		assert.Contains(t, report.Checks[1].Error, "MB free")
+	})
+
+	t.Run("Database closed", func(t *testing.T) {
+		checks := health.NewRegistry(time.Second, 0)
+		require.NoError(t, database.RegisterHealthChecks(checks, db, cfg, 0))
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		report := checks.Run(context.Background(), health.Readiness)
+		assert.Equal(t, health.StatusFail, report.Status)
+		require.Len(t, report.Checks, 1, "no disk check when disabled")
+		assert.Contains(t, report.Checks[0].Error, "closed")
+	})
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/lifecycle"
+	"gin-template/pkg/router"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdownHooksRunInReverseOrder(t *testing.T) {
+	lc := lifecycle.New()
+
+	var order []string
+	lc.OnShutdown("database", func(context.Context) error {
+		order = append(order, "database")
+		return nil
+	})
+	lc.OnShutdown("workers", func(context.Context) error {
+		order = append(order, "workers")
+		return errors.New("worker stuck")
+	})
+	lc.OnShutdown("logs", func(context.Context) error {
+		order = append(order, "logs")
+		return nil
+	})
+
+	err := lc.RunHooks(context.Background())
+
+	assert.Equal(t, []string{"logs", "workers", "database"}, order)
+	var hookErr *lifecycle.HookError
+	require.ErrorAs(t, err, &hookErr)
+	assert.Len(t, hookErr.Errs, 1)
+	assert.ErrorContains(t, err, "workers: worker stuck")
+}
+
+func TestReadinessFailsDuringShutdown(t *testing.T) {
+	lc := lifecycle.New()
+	r := router.New(SetupTestDB(), config.NewProvider(config.Default()), router.WithReadiness(lc.Ready))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, MakeRequest("GET", "/ready", nil))
+	AssertStatusOK(t, w)
+
+	lc.BeginShutdown()
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, MakeRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "shutting down")
+
+	// The process is still alive while it drains
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, MakeRequest("GET", "/livez", nil))
+	AssertStatusOK(t, w)
+}
@@ -0,0 +1,346 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/database"
+	"gin-template/pkg/models"
+	"gin-template/pkg/requestid"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// captureLogs 将全局日志重定向到缓冲区（级别为 info），并返回解析后的日志行
+func captureLogs(t *testing.T) func() []map[string]any {
+	var buf bytes.Buffer
+	config.SetupLoggerOutput(config.LogConfig{Level: "info", Format: "json", Console: config.ConsoleLogConfig{Enabled: true}}, &buf)
+	t.Cleanup(resetLogger)
+
+	return func() []map[string]any {
+		var lines []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			lines = append(lines, entry)
+		}
+		return lines
+	}
+}
+
+// resetLogger 恢复默认日志配置：info 级别，JSON 输出到 stderr
+func resetLogger() {
+	config.SetupLoggerOutput(config.LogConfig{Level: "info", Format: "json", Console: config.ConsoleLogConfig{Enabled: true}}, os.Stderr)
+}
+
+func TestAccessLog(t *testing.T) {
+	router := SetupTestRouter()
+	logs := captureLogs(t)
+
+	req := MakeRequest("POST", "/api/v1/users", models.CreateUserRequest{Name: "Log Test", Email: "log@example.com", Age: 30})
+	req.Header.Set(requestid.Header, "req-123")
+	req.Header.Set("User-Agent", "logging-test")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	router.ServeHTTP(httptest.NewRecorder(), MakeRequest("GET", "/api/v1/users/999", nil))
+	router.ServeHTTP(httptest.NewRecorder(), MakeRequest("GET", "/health", nil))
+
+	lines := logs()
+	require.Len(t, lines, 3, "service log and two access log lines, /health skipped")
+
+	t.Run("Service logs with request fields", func(t *testing.T) {
+		assert.Equal(t, "User created", lines[0]["message"])
+		assert.Equal(t, "req-123", lines[0]["request_id"])
+	})
+
+	t.Run("One structured line per request", func(t *testing.T) {
+		created := lines[1]
+		assert.Equal(t, "info", created["level"])
+		assert.Equal(t, "POST", created["method"])
+		assert.Equal(t, "/api/v1/users", created["route"])
+		assert.EqualValues(t, 200, created["status"])
+		assert.Equal(t, "req-123", created["request_id"])
+		assert.Equal(t, "logging-test", created["user_agent"])
+		assert.Contains(t, created, "latency")
+		assert.Contains(t, created, "bytes")
+		assert.Contains(t, created, "client_ip")
+
+		missing := lines[2]
+		assert.Equal(t, "warn", missing["level"])
+		assert.Equal(t, "/api/v1/users/:id", missing["route"])
+		assert.Equal(t, "/api/v1/users/999", missing["path"])
+		assert.EqualValues(t, 404, missing["status"])
+	})
+}
+
+func TestQueryLogging(t *testing.T) {
+	open := func(t *testing.T, cfg config.DatabaseConfig) *gorm.DB {
+		cfg.Driver, cfg.DSN = "sqlite", ":memory:"
+		db, err := database.New(context.Background(), cfg)
+		require.NoError(t, err)
+		return db
+	}
+	user := &models.User{Name: "Query Log", Email: "secret@example.com", Age: 30}
+
+	t.Run("Parameters are redacted", func(t *testing.T) {
+		db := open(t, config.DatabaseConfig{RedactParams: true})
+		logs := captureLogs(t)
+		require.NoError(t, config.SetLogLevel("debug"))
+
+		require.NoError(t, db.Create(user).Error)
+
+		lines := logs()
+		require.NotEmpty(t, lines)
+		sql := lines[len(lines)-1]["sql"].(string)
+		assert.Contains(t, sql, "INSERT INTO")
+		assert.NotContains(t, sql, "secret@example.com")
+	})
+
+	t.Run("Slow queries are warnings", func(t *testing.T) {
+		db := open(t, config.DatabaseConfig{SlowQueryThreshold: time.Nanosecond})
+		logs := captureLogs(t)
+
+		var count int64
+		require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+
+		lines := logs()
+		require.Len(t, lines, 1)
+		assert.Equal(t, "warn", lines[0]["level"])
+		assert.Equal(t, "Slow query", lines[0]["message"])
+	})
+
+	t.Run("Exceeding the query budget warns once", func(t *testing.T) {
+		db := open(t, config.DatabaseConfig{QueryBudget: 2})
+		logs := captureLogs(t)
+
+		ctx := database.WithQueryStats(context.Background())
+		for i := 0; i < 5; i++ {
+			var users []models.User
+			require.NoError(t, db.WithContext(ctx).Find(&users).Error)
+		}
+
+		assert.EqualValues(t, 5, database.QueryStatsFromContext(ctx).Count())
+		lines := logs()
+		require.Len(t, lines, 1)
+		assert.EqualValues(t, 3, lines[0]["queries"])
+		assert.EqualValues(t, 2, lines[0]["budget"])
+	})
+}
+
+func TestLogSinks(t *testing.T) {
+	var console bytes.Buffer
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Cleanup(func() {
+		resetLogger()
+		config.CloseLogFile()
+	})
+
+	cfg := config.Default().Log
+	cfg.Level = "debug"
+	cfg.Format = "pretty"
+	cfg.Console = config.ConsoleLogConfig{Enabled: true, Level: "warn"}
+	cfg.File.Enabled = true
+	cfg.File.Path = path
+	cfg.File.Level = "info"
+	config.SetupLoggerOutput(cfg, &console)
+
+	logger := config.GetLogger("test")
+	logger.Debug().Msg("debug event")
+	logger.Info().Msg("info event")
+	logger.Warn().Msg("warn event")
+	require.NoError(t, config.CloseLogFile())
+	logger.Warn().Msg("after close")
+
+	t.Run("Console is pretty and filtered", func(t *testing.T) {
+		out := console.String()
+		assert.NotContains(t, out, "info event")
+		assert.Contains(t, out, "warn event")
+		assert.Contains(t, out, "after close", "the console outlives the file")
+		assert.False(t, strings.HasPrefix(out, "{"), "console output is not JSON")
+	})
+
+	t.Run("File is JSON and filtered", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		require.Len(t, lines, 2, "not reopened after close")
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+		assert.Equal(t, "info event", entry["message"])
+		assert.Equal(t, "test", entry["component"])
+	})
+}
+
+func TestLogFileRotateInterval(t *testing.T) {
+	dir := t.TempDir()
+	t.Cleanup(func() {
+		resetLogger()
+		config.CloseLogFile()
+	})
+
+	cfg := config.Default().Log
+	cfg.Console.Enabled = false
+	cfg.File.Enabled = true
+	cfg.File.Path = filepath.Join(dir, "app.log")
+	cfg.File.RotateInterval = 100 * time.Millisecond
+	cfg.File.Compress = false
+	config.SetupLoggerOutput(cfg, io.Discard)
+
+	logger := config.GetLogger("test")
+	logger.Info().Msg("before rotation")
+	assert.Eventually(t, func() bool {
+		backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+		return len(backups) > 0
+	}, 2*time.Second, 20*time.Millisecond)
+
+	logger.Info().Msg("after rotation")
+	require.NoError(t, config.CloseLogFile())
+	data, err := os.ReadFile(cfg.File.Path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "before rotation")
+}
+
+func TestLogReloadWhileLogging(t *testing.T) {
+	t.Cleanup(resetLogger)
+	cfg := config.Default().Log
+	cfg.Format = "json"
+	logger := config.GetLogger("test")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 200 {
+			logger.Info().Msg("component event")
+			log.Info().Msg("global event")
+		}
+	}()
+	for i := range 50 {
+		cfg.Level = []string{"info", "debug"}[i%2]
+		config.SetupLoggerOutput(cfg, io.Discard)
+	}
+	<-done
+
+	// Loggers created before a reload write to the new sinks
+	var buf bytes.Buffer
+	config.SetupLoggerOutput(cfg, &buf)
+	logger.Info().Msg("after reload")
+	assert.Contains(t, buf.String(), "after reload")
+}
+
+func TestLogRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := config.Default().Log
+	cfg.Format = "json"
+	cfg.Redact.Patterns = []string{`secret-\d+`}
+	config.SetupLoggerOutput(cfg, &buf)
+	config.RegisterSensitive(models.Sensitive()...)
+	t.Cleanup(resetLogger)
+
+	logger := config.GetLogger("test")
+	logger.Info().
+		Str("authorization", "Basic dXNlcjpwYXNz").
+		Interface("user", models.User{Name: "Jane", Email: "jane@example.com", Phone: "555-123-4567"}).
+		Int("bytes", 1234567890).
+		Str("note", "sent Bearer abc.def.ghi and secret-42").
+		Msg("Rejected jane@example.com, call +1 555 123 4567")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+	assert.Equal(t, "[REDACTED]", entry["authorization"], "configured field")
+	user := entry["user"].(map[string]any)
+	assert.Equal(t, "[REDACTED]", user["email"], `log:"sensitive" field`)
+	assert.Equal(t, "[REDACTED]", user["phone"], `log:"sensitive" field`)
+	assert.Equal(t, "Jane", user["name"])
+	assert.EqualValues(t, 1234567890, entry["bytes"], "numbers are not patterns")
+	assert.Equal(t, "sent [REDACTED] and [REDACTED]", entry["note"])
+	assert.Equal(t, "Rejected [REDACTED], call [REDACTED]", entry["message"])
+}
+
+func TestInvalidRedactPattern(t *testing.T) {
+	cfg := config.Default()
+	cfg.Log.Redact.Patterns = []string{"("}
+	assert.ErrorContains(t, cfg.Validate(), "log.redact.patterns[0]: must be a valid regular expression")
+}
+
+func TestLogSampling(t *testing.T) {
+	var buf syncBuffer
+	cfg := config.Default().Log
+	cfg.Format = "json"
+	cfg.Sampling = config.LogSamplingConfig{Enabled: true, Period: 200 * time.Millisecond, Burst: 2, Thereafter: 3}
+	config.SetupLoggerOutput(cfg, &buf)
+	t.Cleanup(resetLogger)
+
+	before := config.DroppedLogEvents()
+	logger := config.GetLogger("test")
+	for i := 0; i < 10; i++ {
+		logger.Error().Msg("Parameter binding failed")
+	}
+	logger.Info().Msg("Other event")
+
+	// Events 1 and 2 (burst), then 5 and 8 (1 in 3) of the flooded message
+	assert.Equal(t, 5, strings.Count(buf.String(), "\n"))
+	assert.EqualValues(t, 6, config.DroppedLogEvents()-before)
+
+	// The period ends with a summary of what was dropped, even though no
+	// event follows
+	buf.Reset()
+	require.Eventually(t, func() bool { return buf.String() != "" }, time.Second, 10*time.Millisecond)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	var summary map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &summary))
+	assert.Equal(t, "Log events dropped by sampling", summary["message"])
+	assert.EqualValues(t, 6, summary["dropped"])
+	assert.Equal(t, map[string]any{"Parameter binding failed": 6.0}, summary["messages"])
+
+	// Drops of an unfinished period are reported on shutdown
+	for i := 0; i < 3; i++ {
+		logger.Error().Msg("Parameter binding failed")
+	}
+	buf.Reset()
+	config.FlushLogSampling()
+	assert.Contains(t, buf.String(), `"dropped":1`)
+}
+
+// syncBuffer 可并发读写的缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/router"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	r := SetupTestRouter()
+	r.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	malformed, _ := http.NewRequest("POST", "/api/v1/users", strings.NewReader("{"))
+	malformed.Header.Set("Content-Type", "application/json")
+
+	for _, req := range []*http.Request{
+		MakeRequest("GET", "/api/v1/users/1", nil),
+		MakeRequest("GET", "/api/v1/users/2", nil),
+		MakeRequest("POST", "/api/v1/users", map[string]any{"name": ""}),
+		malformed,
+		MakeRequest("GET", "/panic", nil),
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, MakeRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+
+	// Requests are labelled by route template, not by path
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/api/v1/users/:id",status="404"}`)
+	assert.NotContains(t, body, `route="/api/v1/users/1"`)
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/api/v1/users/:id",status="404"`)
+	assert.Contains(t, body, "http_requests_in_flight")
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/panic",status="500"}`, "panics recovered inside")
+
+	assert.Contains(t, body, `http_binding_failures_total{kind="validation",method="POST",route="/api/v1/users"}`)
+	assert.Contains(t, body, `http_binding_failures_total{kind="decode",method="POST",route="/api/v1/users"}`)
+
+	assert.Contains(t, body, `db_query_duration_seconds_count{operation="query",outcome="success"}`)
+	assert.Contains(t, body, `go_sql_open_connections{db_name="main"}`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestMetricsOnAdminListener(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Admin.Enabled = true
+	provider := config.NewProvider(cfg)
+
+	w := httptest.NewRecorder()
+	router.New(SetupTestDB(), provider).ServeHTTP(w, MakeRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.NewAdmin(provider).ServeHTTP(w, MakeRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain"))
+}
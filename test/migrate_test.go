@@ -0,0 +1,218 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/database"
+	"gin-template/pkg/migrate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// openUnmigrated 打开一个未迁移的 SQLite 数据库文件
+func openUnmigrated(t *testing.T, path string) (*gorm.DB, config.DatabaseConfig) {
+	cfg := config.DatabaseConfig{
+		Driver:             "sqlite",
+		DSN:                config.Secret(path),
+		SQLite:             config.SQLiteConfig{BusyTimeout: 5 * time.Second},
+		MigrateLockTimeout: 5 * time.Second,
+	}
+	db, err := database.Open(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+	return db, cfg
+}
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, cfg := openUnmigrated(t, filepath.Join(t.TempDir(), "app.db"))
+	migrator, err := database.NewMigrator(db, cfg)
+	require.NoError(t, err)
+
+	pending, err := migrator.Pending(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "0001 create_users", pending[0].String())
+	assert.Equal(t, "0002 index_users_created_at", pending[1].String())
+	assert.NotNil(t, pending[0].Up.Func)
+	assert.Contains(t, pending[1].Up.SQL, "CREATE INDEX idx_users_created_at")
+	assert.False(t, db.Migrator().HasTable("users"), "dry run changes nothing")
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.False(t, db.Migrator().HasTable("schema_migrations"), "nor does reading the status")
+
+	t.Run("Up to a version", func(t *testing.T) {
+		applied, err := migrator.Up(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.True(t, db.Migrator().HasTable("users"))
+		assert.False(t, db.Migrator().HasIndex("users", "idx_users_created_at"))
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		assert.True(t, statuses[0].Applied)
+		assert.False(t, statuses[0].AppliedAt.IsZero())
+		assert.False(t, statuses[1].Applied)
+	})
+
+	t.Run("Up to the latest", func(t *testing.T) {
+		applied, err := migrator.Up(ctx, 0)
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.True(t, db.Migrator().HasIndex("users", "idx_users_created_at"))
+
+		applied, err = migrator.Up(ctx, 0)
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+	})
+
+	t.Run("Down and up again", func(t *testing.T) {
+		rollbacks, err := migrator.Rollbacks(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, rollbacks, 1)
+		assert.Equal(t, int64(2), rollbacks[0].Version)
+
+		reverted, err := migrator.Down(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, reverted, 2)
+		assert.False(t, db.Migrator().HasTable("users"))
+
+		applied, err := migrator.Up(ctx, 0)
+		require.NoError(t, err)
+		assert.Len(t, applied, 2)
+	})
+
+	t.Run("Unknown versions", func(t *testing.T) {
+		older, err := migrate.New(db, []migrate.Migration{
+			{Version: 1, Name: "create_users", Up: migrate.SQL("SELECT 1;")},
+		}, time.Second)
+		require.NoError(t, err)
+
+		statuses, err := older.Status(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		assert.True(t, statuses[1].Unknown)
+		assert.Equal(t, "index_users_created_at", statuses[1].Name)
+	})
+}
+
+func TestMigrationsMatchAutoMigrate(t *testing.T) {
+	versioned, cfg := openUnmigrated(t, filepath.Join(t.TempDir(), "versioned.db"))
+	require.NoError(t, database.Migrate(context.Background(), versioned, cfg))
+
+	auto, cfg := openUnmigrated(t, filepath.Join(t.TempDir(), "auto.db"))
+	cfg.Migrate = "auto"
+	require.NoError(t, database.Migrate(context.Background(), auto, cfg))
+
+	describe := func(db *gorm.DB) (columns, indexes []string) {
+		columnTypes, err := db.Migrator().ColumnTypes("users")
+		require.NoError(t, err)
+		for _, column := range columnTypes {
+			columns = append(columns, column.Name()+" "+column.DatabaseTypeName())
+		}
+		require.NoError(t, db.Raw(
+			"SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'users' ORDER BY name",
+		).Scan(&indexes).Error)
+		return columns, indexes
+	}
+
+	versionedColumns, versionedIndexes := describe(versioned)
+	autoColumns, autoIndexes := describe(auto)
+	assert.Equal(t, autoColumns, versionedColumns)
+	assert.Equal(t, autoIndexes, versionedIndexes)
+	assert.Contains(t, versionedIndexes, "idx_users_created_at")
+}
+
+func TestMigrationsLoadSQLFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0001_create_posts.up.sql":         {Data: []byte("CREATE TABLE posts (id INTEGER);\n-- comment\nCREATE INDEX idx_posts_id\n  ON posts (id);\n")},
+		"migrations/0001_create_posts.down.sql":       {Data: []byte("DROP TABLE posts;")},
+		"migrations/0001_create_posts.down.mysql.sql": {Data: []byte("DROP TABLE IF EXISTS posts;")},
+		"migrations/0002_seed.up.postgres.sql":        {Data: []byte("INSERT INTO posts VALUES (1);")},
+		"migrations/README.md":                        {Data: []byte("not a migration")},
+	}
+
+	migrations, err := migrate.Load(fsys, "migrations", "mysql")
+	require.NoError(t, err)
+	require.Len(t, migrations, 1, "driver-specific migrations of other drivers are skipped")
+	assert.Equal(t, "DROP TABLE IF EXISTS posts;", migrations[0].Down.SQL)
+
+	migrations, err = migrate.Load(fsys, "migrations", "sqlite")
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+	assert.Equal(t, "DROP TABLE posts;", migrations[0].Down.SQL)
+
+	db, _ := openUnmigrated(t, filepath.Join(t.TempDir(), "app.db"))
+	migrator, err := migrate.New(db, migrations, time.Second)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasIndex("posts", "idx_posts_id"))
+
+	_, err = migrate.New(db, []migrate.Migration{{Version: 3, Name: "empty"}}, time.Second)
+	assert.ErrorContains(t, err, "no up step")
+}
+
+func TestMigrationLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.db")
+
+	t.Run("Concurrent instances migrate once", func(t *testing.T) {
+		var wg sync.WaitGroup
+		results := make([][]migrate.Migration, 3)
+		errs := make([]error, 3)
+		for i := range results {
+			db, cfg := openUnmigrated(t, path)
+			migrator, err := database.NewMigrator(db, cfg)
+			require.NoError(t, err)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], errs[i] = migrator.Up(context.Background(), 0)
+			}()
+		}
+		wg.Wait()
+
+		total := 0
+		for i := range results {
+			require.NoError(t, errs[i])
+			total += len(results[i])
+		}
+		assert.Equal(t, 2, total, "each migration applied exactly once")
+	})
+
+	t.Run("Times out while held", func(t *testing.T) {
+		db, cfg := openUnmigrated(t, path)
+		require.NoError(t, db.Exec(
+			"INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (1, 'other-host:42', ?)", time.Now(),
+		).Error)
+
+		cfg.MigrateLockTimeout = 300 * time.Millisecond
+		migrator, err := database.NewMigrator(db, cfg)
+		require.NoError(t, err)
+		_, err = migrator.Up(context.Background(), 0)
+		assert.ErrorContains(t, err, "held by other-host:42")
+
+		released, err := migrator.Unlock(context.Background())
+		require.NoError(t, err)
+		assert.True(t, released)
+		_, err = migrator.Up(context.Background(), 0)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,158 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/database"
+	"gin-template/pkg/models"
+	"gin-template/pkg/router"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// openReplicaFile 打开一个 SQLite 数据库文件，写入名为 name 的用户
+//
+// The primary and replicas are separate files holding different users, so
+// the result of a read shows which database served it.
+func openReplicaFile(t *testing.T, path, name string) *gorm.DB {
+	cfg := config.DatabaseConfig{Driver: "sqlite", DSN: config.Secret(path), Migrate: "auto"}
+	db, err := database.New(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+	if name != "" {
+		require.NoError(t, db.Create(&models.User{Name: name, Email: "user@example.com", Age: 30}).Error)
+	}
+	return db
+}
+
+// readUserName 以 client 的身份读取用户 1 的名字
+func readUserName(t *testing.T, r http.Handler, client string) string {
+	req := MakeRequest("GET", "/api/v1/users/1", nil)
+	req.RemoteAddr = client + ":1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	AssertStatusOK(t, w)
+
+	var response struct {
+		Data models.User `json:"data"`
+	}
+	ParseResponseBody(t, w, &response)
+	return response.Data.Name
+}
+
+// replicasConfig 返回以 dir 中的 primary.db 为主库、replicas 为从库的配置
+func replicasConfig(dir string, replicas ...string) config.DatabaseConfig {
+	cfg := config.DatabaseConfig{Driver: "sqlite", DSN: config.Secret(filepath.Join(dir, "primary.db"))}
+	cfg.Replicas = config.ReplicasConfig{
+		Policy:         "round_robin",
+		StickyWindow:   time.Minute,
+		HealthInterval: time.Second,
+	}
+	for _, replica := range replicas {
+		cfg.Replicas.DSNs = append(cfg.Replicas.DSNs, config.Secret(filepath.Join(dir, replica)))
+	}
+	return cfg
+}
+
+func TestReplicasRoundRobin(t *testing.T) {
+	dir := t.TempDir()
+	primary := openReplicaFile(t, filepath.Join(dir, "primary.db"), "Primary")
+	openReplicaFile(t, filepath.Join(dir, "replica-1.db"), "Replica One")
+	openReplicaFile(t, filepath.Join(dir, "replica-2.db"), "Replica Two")
+
+	cluster := database.OpenCluster(context.Background(), primary, replicasConfig(dir, "replica-1.db", "replica-2.db"))
+	t.Cleanup(func() { _ = cluster.Close() })
+	r := router.New(primary, config.NewProvider(config.Default()), router.WithCluster(cluster))
+
+	var names []string
+	for range 4 {
+		names = append(names, readUserName(t, r, "192.0.2.1"))
+	}
+	assert.Equal(t, []string{"Replica One", "Replica Two", "Replica One", "Replica Two"}, names)
+
+	stats, err := cluster.Stats()
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+	assert.Equal(t, "replica-2", stats[2].Name)
+	assert.True(t, stats[2].Healthy)
+}
+
+func TestReplicasReadYourWrites(t *testing.T) {
+	dir := t.TempDir()
+	primary := openReplicaFile(t, filepath.Join(dir, "primary.db"), "")
+	openReplicaFile(t, filepath.Join(dir, "replica.db"), "Replica")
+
+	cfg := replicasConfig(dir, "replica.db")
+	cfg.Replicas.StickyWindow = 300 * time.Millisecond
+	cluster := database.OpenCluster(context.Background(), primary, cfg)
+	t.Cleanup(func() { _ = cluster.Close() })
+	r := router.New(primary, config.NewProvider(config.Default()), router.WithCluster(cluster))
+
+	req := MakeRequest("POST", "/api/v1/users", models.CreateUserRequest{Name: "Written", Email: "written@example.com", Age: 20})
+	req.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	AssertStatusOK(t, w)
+
+	assert.Equal(t, "Written", readUserName(t, r, "192.0.2.1"), "the writer reads the primary")
+	assert.Equal(t, "Replica", readUserName(t, r, "192.0.2.2"), "other clients read the replica")
+
+	time.Sleep(cfg.Replicas.StickyWindow + 50*time.Millisecond)
+	assert.Equal(t, "Replica", readUserName(t, r, "192.0.2.1"), "back to the replica after the window")
+}
+
+func TestReplicasUnhealthyFallback(t *testing.T) {
+	dir := t.TempDir()
+	primary := openReplicaFile(t, filepath.Join(dir, "primary.db"), "Primary")
+
+	// The replica's directory does not exist yet, so it cannot be opened
+	cfg := replicasConfig(dir, filepath.Join("not-yet", "replica.db"))
+	cfg.Replicas.HealthInterval = 50 * time.Millisecond
+	cluster := database.OpenCluster(context.Background(), primary, cfg)
+	t.Cleanup(func() { _ = cluster.Close() })
+	r := router.New(primary, config.NewProvider(config.Default()), router.WithCluster(cluster))
+
+	assert.Equal(t, "Primary", readUserName(t, r, "192.0.2.1"))
+	stats, err := cluster.Stats()
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.False(t, stats[1].Healthy)
+
+	// The monitor connects once the replica is reachable
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "not-yet"), 0o755))
+	openReplicaFile(t, filepath.Join(dir, "not-yet", "replica.db"), "Replica")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cluster.Monitor(ctx)
+
+	assert.Eventually(t, func() bool {
+		return readUserName(t, r, "192.0.2.1") == "Replica"
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestReplicasConfigFromEnv(t *testing.T) {
+	t.Setenv("DB_REPLICA_DSNS", "replica-1.db,replica-2.db")
+	t.Setenv("DB_REPLICA_POLICY", "least_connections")
+
+	cfg, err := config.Load(writeFile(t, t.TempDir(), "config.yaml", "env: dev\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []config.Secret{"replica-1.db", "replica-2.db"}, cfg.Database.Replicas.DSNs)
+	assert.Equal(t, "least_connections", cfg.Database.Replicas.Policy)
+	assert.Equal(t, 5*time.Second, cfg.Database.Replicas.StickyWindow)
+
+	t.Setenv("DB_REPLICA_POLICY", "fastest")
+	_, err = config.Load(writeFile(t, t.TempDir(), "config.yaml", "env: dev\n"))
+	assert.ErrorContains(t, err, "policy")
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/middleware"
+	"gin-template/pkg/requestid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	router := SetupTestRouter()
+
+	t.Run("Generated when missing", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, MakeRequest("GET", "/health", nil))
+		assert.Len(t, w.Header().Get(requestid.Header), 32)
+	})
+
+	t.Run("Client ID is echoed", func(t *testing.T) {
+		req := MakeRequest("GET", "/health", nil)
+		req.Header.Set(requestid.Header, "abc-123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, "abc-123", w.Header().Get(requestid.Header))
+	})
+
+	t.Run("Invalid client ID is replaced", func(t *testing.T) {
+		req := MakeRequest("GET", "/health", nil)
+		req.Header.Set(requestid.Header, strings.Repeat("x", 200))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Len(t, w.Header().Get(requestid.Header), 32)
+	})
+
+	t.Run("Error responses carry the ID", func(t *testing.T) {
+		req := MakeRequest("GET", "/api/v1/users/999", nil)
+		req.Header.Set(requestid.Header, "abc-404")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		AssertStatusNotFound(t, w)
+
+		var resp middleware.Response
+		ParseResponseBody(t, w, &resp)
+		assert.Equal(t, "abc-404", resp.RequestID)
+	})
+}
+
+func TestRequestIDInQueryLogs(t *testing.T) {
+	router := SetupTestRouter()
+	logs := captureLogs(t)
+	require.NoError(t, config.SetLogLevel("debug"))
+
+	req := MakeRequest("GET", "/api/v1/users/999", nil)
+	req.Header.Set(requestid.Header, "abc-sql")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var queries int
+	for _, line := range logs() {
+		if line["component"] == "database" {
+			queries++
+			assert.Equal(t, "abc-sql", line["request_id"])
+		}
+	}
+	assert.Positive(t, queries)
+}
+
+func TestRequestIDTransport(t *testing.T) {
+	var received string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(requestid.Header)
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: &requestid.Transport{}}
+	ctx := requestid.NewContext(context.Background(), "abc-out")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "abc-out", received)
+	assert.Empty(t, req.Header.Get(requestid.Header), "caller's request is not modified")
+}
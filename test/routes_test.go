@@ -0,0 +1,26 @@
+package test
+
+import (
+	"testing"
+
+	"gin-template/pkg/router"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutesReportBoundTypes(t *testing.T) {
+	routes := router.Routes(SetupTestRouter())
+
+	byKey := make(map[string]router.RouteInfo)
+	for _, r := range routes {
+		byKey[r.Method+" "+r.Path] = r
+	}
+
+	create := byKey["POST /api/v1/users"]
+	assert.Equal(t, "controller.(*UserController).CreateUser", create.Handler)
+	assert.Equal(t, []string{"models.CreateUserRequest"}, create.Binds)
+
+	get := byKey["GET /api/v1/users/:id"]
+	assert.Equal(t, "controller.(*UserController).GetUser", get.Handler)
+	assert.Empty(t, get.Binds)
+}
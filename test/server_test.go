@@ -0,0 +1,213 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA 测试用证书颁发机构
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key}
+}
+
+// issue 签发证书并以 PEM 格式返回证书和私钥
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (ca *testCA) pem() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+}
+
+// freePort 获取一个空闲端口
+func freePort(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	return port
+}
+
+// TestServerMutualTLS also guards the Go 1.22 ServeTLS path, which loads
+// certificate files unless the TLS config has GetCertificate; CI runs it on
+// every Go version in the matrix.
+func TestServerMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, 3, x509.ExtKeyUsageClientAuth)
+
+	cfg := config.Default().Server
+	cfg.Port = freePort(t)
+	cfg.TLS = config.TLSConfig{
+		Enabled:      true,
+		CertFile:     writeFile(t, dir, "server.crt", string(serverCert)),
+		KeyFile:      writeFile(t, dir, "server.key", string(serverKey)),
+		ClientAuth:   "require",
+		ClientCAFile: writeFile(t, dir, "ca.crt", string(ca.pem())),
+		MinVersion:   "1.2",
+	}
+
+	srv, err := server.New(cfg, SetupTestRouter())
+	require.NoError(t, err)
+	go srv.ListenAndServe()
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	url := "https://127.0.0.1:" + cfg.Port + "/health"
+
+	// 等待服务器启动
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", "127.0.0.1:"+cfg.Port)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	t.Run("Client without certificate is rejected", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots},
+		}}
+		_, err := client.Get(url)
+		assert.Error(t, err)
+	})
+
+	t.Run("Client with certificate is accepted", func(t *testing.T) {
+		pair, err := tls.X509KeyPair(clientCert, clientKey)
+		require.NoError(t, err)
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}},
+		}}
+		resp, err := client.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestServerRejectsMissingCertificate(t *testing.T) {
+	cfg := config.Default().Server
+	cfg.TLS.Enabled = true
+	cfg.TLS.CertFile = filepath.Join(os.TempDir(), "missing.crt")
+	cfg.TLS.KeyFile = filepath.Join(os.TempDir(), "missing.key")
+
+	_, err := server.New(cfg, http.NotFoundHandler())
+	assert.ErrorContains(t, err, "load TLS certificate")
+}
+
+func TestServerListensOnUnixSocketAndTCP(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	port := freePort(t)
+
+	cfg := config.Default().Server
+	cfg.Listen = []string{"tcp://127.0.0.1:" + port, "unix://" + socket}
+	cfg.UnixSocket.Mode = "0600"
+
+	srv, err := server.New(cfg, SetupTestRouter())
+	require.NoError(t, err)
+	go srv.ListenAndServe()
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+
+	// 等待服务器启动
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(socket)
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	t.Run("Same API on both listeners", func(t *testing.T) {
+		unixClient := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}}
+		resp, err := unixClient.Get("http://unix/health")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = http.Get("http://127.0.0.1:" + port + "/health")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Socket has configured mode", func(t *testing.T) {
+		info, err := os.Stat(socket)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+}
+
+func TestInvalidListenAddress(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Listen = []string{"udp://:53"}
+	cfg.Server.UnixSocket.Mode = "rw-rw----"
+
+	err := cfg.Validate()
+	var verr *config.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Len(t, verr.Problems, 2)
+}
@@ -0,0 +1,210 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gin-template/pkg/config"
+	"gin-template/pkg/models"
+	"gin-template/pkg/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordSpans 安装记录所有 span 的全局 TracerProvider，测试结束后恢复
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	return recorder
+}
+
+func TestTracing(t *testing.T) {
+	router := SetupTestRouter()
+	recorder := recordSpans(t)
+	logs := captureLogs(t)
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	req := MakeRequest("POST", "/api/v1/users", models.CreateUserRequest{
+		Name:  "Trace",
+		Email: "trace@example.com",
+		Age:   30,
+	})
+	req.Header.Set("traceparent", "00-"+traceID+"-"+spanID+"-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String(), span.Name())
+	}
+
+	server := spans["POST /api/v1/users"]
+	require.NotNil(t, server)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, spanID, server.Parent().SpanID().String(), "continues the incoming trace")
+
+	bind := spans["bind CreateUserRequest"]
+	require.NotNil(t, bind)
+	assert.Equal(t, server.SpanContext().SpanID(), bind.Parent().SpanID())
+
+	service := spans["UserService.CreateUser"]
+	require.NotNil(t, service)
+	assert.Equal(t, server.SpanContext().SpanID(), service.Parent().SpanID())
+
+	query := spans["create users"]
+	require.NotNil(t, query)
+	assert.Equal(t, trace.SpanKindClient, query.SpanKind())
+	assert.Equal(t, service.SpanContext().SpanID(), query.Parent().SpanID())
+
+	// Log lines written during the request carry the trace ID
+	var created map[string]any
+	for _, line := range logs() {
+		if line["message"] == "User created" {
+			created = line
+		}
+	}
+	require.NotNil(t, created)
+	assert.Equal(t, traceID, created["trace_id"])
+	assert.Equal(t, server.SpanContext().SpanID().String(), created["span_id"])
+}
+
+func TestTracingFailedBinding(t *testing.T) {
+	router := SetupTestRouter()
+	recorder := recordSpans(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, MakeRequest("POST", "/api/v1/users", map[string]any{"name": ""}))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var bind sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "bind CreateUserRequest" {
+			bind = span
+		}
+	}
+	require.NotNil(t, bind)
+	assert.Equal(t, "Error", bind.Status().Code.String())
+	assert.NotEmpty(t, bind.Events(), "the binding error is recorded")
+}
+
+func TestTracingFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.json")
+	cfg := config.Default().Tracing
+	cfg.Enabled = true
+	cfg.Exporter = "file"
+	cfg.Path = path
+
+	shutdown, err := tracing.Setup(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	_, span := tracing.Start(context.Background(), "offline")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"offline"`)
+	assert.Contains(t, string(data), `"Value":"gin-template"`, "service name")
+}
+
+func TestTracingOTLPEndpoint(t *testing.T) {
+	var paths []string
+	var mu sync.Mutex
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	cfg := config.Default().Tracing
+	cfg.Enabled = true
+	cfg.Endpoint = collector.URL + "/"
+
+	shutdown, err := tracing.Setup(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	_, span := tracing.Start(context.Background(), "exported")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"/v1/traces"}, paths, "a trailing slash on the endpoint is ignored")
+}
+
+func TestTracingEndpointValidation(t *testing.T) {
+	for _, endpoint := range []string{"localhost:4318", "http://", "http://collector:4318?tenant=a"} {
+		cfg := config.Default()
+		cfg.Tracing.Endpoint = endpoint
+		assert.ErrorContains(t, cfg.Validate(), "tracing.endpoint: must be an http or https URL", endpoint)
+	}
+
+	cfg := config.Default()
+	cfg.Tracing.Endpoint = "https://collector.example.com/otlp/"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestTracingDisabledByDefault(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), config.Default().Tracing)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	_, span := tracing.Start(context.Background(), "ignored")
+	defer span.End()
+	assert.False(t, span.SpanContext().IsValid())
+}
+
+func TestTracingTransport(t *testing.T) {
+	recordSpans(t)
+
+	var received string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	ctx, span := tracing.Start(context.Background(), "outbound")
+	defer span.End()
+
+	client := &http.Client{Transport: &tracing.Transport{}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	sc := span.SpanContext()
+	assert.Equal(t, "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01", received)
+	assert.Empty(t, req.Header.Get("traceparent"), "caller's request is not modified")
+}
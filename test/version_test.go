@@ -0,0 +1,36 @@
+package test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"gin-template/pkg/middleware"
+	"gin-template/pkg/version"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionEndpoint(t *testing.T) {
+	router := SetupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, MakeRequest("GET", "/version", nil))
+	AssertStatusOK(t, w)
+	assert.Equal(t, version.Version, w.Header().Get(middleware.VersionHeader))
+
+	var resp struct {
+		Data version.Info `json:"data"`
+	}
+	ParseResponseBody(t, w, &resp)
+	assert.Equal(t, version.Get(), resp.Data)
+	assert.NotEmpty(t, resp.Data.GoVersion)
+}
+
+func TestVersionHeaderOnAPIResponses(t *testing.T) {
+	router := SetupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, MakeRequest("GET", "/api/v1/users/999", nil))
+	AssertStatusNotFound(t, w)
+	assert.Equal(t, version.Version, w.Header().Get(middleware.VersionHeader))
+}